@@ -0,0 +1,72 @@
+package mcpserve
+
+import (
+	"context"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// ActivityKind identifies an MCP activity event
+type ActivityKind string
+
+const (
+	ActivityClientConnected ActivityKind = "client_connected" // A client completed initialize
+	ActivityToolStarted     ActivityKind = "tool_started"
+	ActivityToolFinished    ActivityKind = "tool_finished" // Duration and Status are set
+	ActivityNotification    ActivityKind = "notification"  // Method is set
+)
+
+// ActivityEvent describes something an MCP client did or was sent
+type ActivityEvent struct {
+	Kind     ActivityKind
+	Time     time.Time
+	Session  string
+	Client   string // "name/version" from the MCP initialize request, when known
+	Tool     string
+	Duration time.Duration
+	Status   string // "ok", "error" or "panic"
+	Method   string // Notification method
+}
+
+// ActivityListener is optionally implemented by the TUI passed to NewHandler to render an
+// MCP activity panel. OnMCPActivity is called synchronously from the server and must not block.
+type ActivityListener interface {
+	OnMCPActivity(event ActivityEvent)
+}
+
+// emitActivity sends event to the TUI when it implements ActivityListener
+func (h *Handler) emitActivity(ctx context.Context, event ActivityEvent) {
+	listener, ok := h.tui.(ActivityListener)
+	if !ok {
+		return
+	}
+	event.Time = time.Now()
+	if event.Session == "" {
+		event.Session = sessionID(ctx)
+	}
+	if event.Client == "" {
+		event.Client = clientName(ctx)
+	}
+	listener.OnMCPActivity(event)
+}
+
+// activityHooks reports client connections to the activity listener
+func (h *Handler) activityHooks() *server.Hooks {
+	hooks := &server.Hooks{}
+	hooks.AddAfterInitialize(func(ctx context.Context, id any, message *mcp.InitializeRequest, result *mcp.InitializeResult) {
+		info := message.Params.ClientInfo
+		h.emitActivity(ctx, ActivityEvent{
+			Kind:   ActivityClientConnected,
+			Client: info.Name + "/" + info.Version,
+		})
+	})
+	return hooks
+}
+
+// NotifyClients sends a JSON-RPC notification to every connected client session
+func (h *Handler) NotifyClients(method string, params map[string]any) {
+	h.MCPServer().SendNotificationToAllClients(method, params)
+	h.emitActivity(context.Background(), ActivityEvent{Kind: ActivityNotification, Method: method})
+}
@@ -0,0 +1,81 @@
+package mcpserve
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// activityTUI records MCP activity events
+type activityTUI struct {
+	mockTUI
+	mu     sync.Mutex
+	events []ActivityEvent
+}
+
+func (a *activityTUI) OnMCPActivity(event ActivityEvent) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.events = append(a.events, event)
+}
+
+func (a *activityTUI) kinds() []ActivityKind {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	kinds := []ActivityKind{}
+	for _, e := range a.events {
+		kinds = append(kinds, e.Kind)
+	}
+	return kinds
+}
+
+// TestActivityEvents verifies connection, tool and notification events reach the TUI
+func TestActivityEvents(t *testing.T) {
+	tui := &activityTUI{}
+	handler := NewHandler(Config{Port: "0"}, []any{&mockHandler{}}, tui)
+	startTestHandler(t, handler)
+	defer handler.Shutdown(context.Background())
+
+	post := func(body string) {
+		req, _ := http.NewRequest("POST", mcpServerURL(handler.Port()), strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set("Accept", "application/json, text/event-stream")
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	post(`{"jsonrpc":"2.0","id":1,"method":"initialize","params":{"protocolVersion":"2025-03-26","capabilities":{},"clientInfo":{"name":"agent","version":"1.2"}}}`)
+	post(`{"jsonrpc":"2.0","id":2,"method":"tools/call","params":{"name":"test_tool"}}`)
+	handler.NotifyClients("notifications/message", map[string]any{"level": "info", "data": "hi"})
+
+	want := []ActivityKind{ActivityClientConnected, ActivityToolStarted, ActivityToolFinished, ActivityNotification}
+	got := tui.kinds()
+	if strings.Join(toStrings(got), ",") != strings.Join(toStrings(want), ",") {
+		t.Fatalf("Expected %v, got %v", want, got)
+	}
+
+	tui.mu.Lock()
+	defer tui.mu.Unlock()
+	if tui.events[0].Client != "agent/1.2" {
+		t.Errorf("Unexpected client %q", tui.events[0].Client)
+	}
+	finished := tui.events[2]
+	if finished.Tool != "test_tool" || finished.Status != "ok" || finished.Duration <= 0 {
+		t.Errorf("Unexpected finished event %+v", finished)
+	}
+	if tui.events[3].Method != "notifications/message" {
+		t.Errorf("Unexpected notification event %+v", tui.events[3])
+	}
+}
+
+func toStrings(kinds []ActivityKind) []string {
+	out := make([]string, len(kinds))
+	for i, k := range kinds {
+		out[i] = string(k)
+	}
+	return out
+}
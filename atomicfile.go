@@ -0,0 +1,135 @@
+package mcpserve
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+const (
+	lockRetryInterval = 50 * time.Millisecond
+	lockTimeout       = 5 * time.Second
+	lockStaleAfter    = 30 * time.Second            // A lock this old belongs to a crashed process
+	maxConfigBackups  = 3                           // Older backups are pruned
+	backupTimeLayout  = "20060102-150405.000000000" // Fixed width, so names sort by time
+)
+
+// errLockTimeout is returned when another process holds the config lock too long
+var errLockTimeout = errors.New("timed out waiting for config lock")
+
+// lockFile takes an exclusive advisory lock on path by creating "<path>.lock".
+// Works on every platform since it only relies on O_EXCL.
+// The returned unlock func removes the lock file.
+// A symlinked path is locked at its target, like writeFileAtomic writes it.
+func lockFile(path string) (unlock func(), err error) {
+	lockPath := resolveSymlink(path) + ".lock"
+	deadline := time.Now().Add(lockTimeout)
+
+	for {
+		f, err := os.OpenFile(lockPath, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			fmt.Fprintf(f, "%d\n", os.Getpid())
+			f.Close()
+			return func() { os.Remove(lockPath) }, nil
+		}
+		if !os.IsExist(err) {
+			return nil, err
+		}
+
+		// Break stale locks left behind by crashed processes
+		if info, statErr := os.Stat(lockPath); statErr == nil && time.Since(info.ModTime()) > lockStaleAfter {
+			breakStaleLock(lockPath)
+			continue
+		}
+
+		if time.Now().After(deadline) {
+			return nil, errLockTimeout
+		}
+		time.Sleep(lockRetryInterval)
+	}
+}
+
+// breakStaleLock removes a lock found stale. Another waiter may have broken it and taken a
+// fresh lock since our check, so the lock is first renamed to a unique name (only one waiter
+// gets it) and checked again; a fresh lock is put back unless a newer one took its place.
+func breakStaleLock(lockPath string) {
+	stalePath := fmt.Sprintf("%s.stale-%d-%d", lockPath, os.Getpid(), time.Now().UnixNano())
+	if err := os.Rename(lockPath, stalePath); err != nil {
+		return // Already broken by another waiter
+	}
+	defer os.Remove(stalePath)
+
+	if info, err := os.Stat(stalePath); err == nil && time.Since(info.ModTime()) <= lockStaleAfter {
+		os.Link(stalePath, lockPath) // Fails, without replacing it, when lockPath exists again
+	}
+}
+
+// writeFileAtomic writes data to a temp file in the same directory and renames it over path,
+// so readers never observe a partially written file. When path is a symlink (e.g. a config kept
+// in a dotfiles repo) its target is written and the link is kept.
+func writeFileAtomic(path string, data []byte, perm os.FileMode) error {
+	path = resolveSymlink(path)
+	tmp, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+
+	// Remove the temp file on any failure before the rename
+	fail := func(err error) error {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if _, err := tmp.Write(data); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Sync(); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Chmod(perm); err != nil {
+		return fail(err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return err
+	}
+	return nil
+}
+
+// resolveSymlink returns the file path points to, or path itself when it doesn't resolve
+func resolveSymlink(path string) string {
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// backupFile stores data as "<path>.bak-<timestamp>" and prunes the oldest backups.
+func backupFile(path string, data []byte, perm os.FileMode) error {
+	backupPath := path + ".bak-" + time.Now().Format(backupTimeLayout)
+	if err := writeFileAtomic(backupPath, data, perm); err != nil {
+		return err
+	}
+
+	backups, err := filepath.Glob(path + ".bak-*")
+	if err != nil {
+		return nil // Pruning is best effort
+	}
+	// Timestamps sort lexically, oldest first
+	sort.Strings(backups)
+	for len(backups) > maxConfigBackups {
+		os.Remove(backups[0])
+		backups = backups[1:]
+	}
+	return nil
+}
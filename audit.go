@@ -0,0 +1,264 @@
+package mcpserve
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+const (
+	defaultAuditMaxSize  = 10 << 20 // 10 MiB
+	defaultAuditMaxFiles = 3
+	redactedValue        = "[REDACTED]"
+)
+
+// defaultAuditRedact are argument name fragments whose values never reach the audit log
+var defaultAuditRedact = []string{"password", "passwd", "secret", "token", "apikey", "api_key", "authorization", "credential", "private_key"}
+
+// AuditEntry is one tool invocation in the audit log (one JSON line)
+type AuditEntry struct {
+	Time       time.Time      `json:"time"`
+	Session    string         `json:"session,omitempty"`
+	Client     string         `json:"client,omitempty"` // "name/version" from the MCP initialize request
+	Tool       string         `json:"tool"`
+	Args       map[string]any `json:"args,omitempty"`  // Sensitive values replaced by "[REDACTED]"
+	Outcome    string         `json:"outcome"`         // "ok", "error" or "panic"
+	Error      string         `json:"error,omitempty"` // Error result text
+	DurationMs float64        `json:"durationMs"`
+	OutputSize int            `json:"outputSize"` // Bytes of result content
+}
+
+// AuditQuery filters AuditEntries; zero fields match everything
+type AuditQuery struct {
+	Tool    string
+	Session string
+	Since   time.Time
+	Limit   int // Max entries returned (default 100)
+}
+
+// auditLog appends entries to a JSON Lines file, rotating it by size into
+// <path>.1 (newest) ... <path>.<maxFiles>
+type auditLog struct {
+	mu       sync.Mutex
+	path     string
+	maxSize  int64
+	maxFiles int
+	redact   []string // Lowercase argument name fragments
+	file     *os.File
+	size     int64
+}
+
+func newAuditLog(config Config) *auditLog {
+	if config.AuditLogPath == "" {
+		return nil
+	}
+	a := &auditLog{
+		path:     config.AuditLogPath,
+		maxSize:  config.AuditMaxSize,
+		maxFiles: config.AuditMaxFiles,
+	}
+	if a.maxSize <= 0 {
+		a.maxSize = defaultAuditMaxSize
+	}
+	if a.maxFiles <= 0 {
+		a.maxFiles = defaultAuditMaxFiles
+	}
+	a.redact = redactFragments(config)
+	return a
+}
+
+// redactFragments returns the lowercase argument name fragments whose values are redacted
+func redactFragments(config Config) []string {
+	var fragments []string
+	for _, name := range append(defaultAuditRedact, config.AuditRedact...) {
+		fragments = append(fragments, strings.ToLower(name))
+	}
+	return fragments
+}
+
+// matchesFragment reports whether name contains one of the lowercase fragments, ignoring case
+func matchesFragment(name string, fragments []string) bool {
+	name = strings.ToLower(name)
+	for _, fragment := range fragments {
+		if strings.Contains(name, fragment) {
+			return true
+		}
+	}
+	return false
+}
+
+// sensitive reports whether the argument name matches a redaction fragment
+func (a *auditLog) sensitive(name string) bool {
+	return matchesFragment(name, a.redact)
+}
+
+// write appends one entry, rotating first when the file would exceed maxSize
+func (a *auditLog) write(entry AuditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	line = append(line, '\n')
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.file == nil {
+		if err := a.open(); err != nil {
+			return err
+		}
+	}
+	if a.size > 0 && a.size+int64(len(line)) > a.maxSize {
+		if err := a.rotate(); err != nil {
+			return err
+		}
+	}
+
+	n, err := a.file.Write(line)
+	a.size += int64(n)
+	return err
+}
+
+// open opens the current file for appending; the log may contain secrets in free-form args, hence 0600
+func (a *auditLog) open() error {
+	if err := os.MkdirAll(filepath.Dir(a.path), 0755); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(a.path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	a.file, a.size = file, info.Size()
+	return nil
+}
+
+// rotate shifts <path>.N to <path>.N+1, dropping the oldest, and starts a new file
+func (a *auditLog) rotate() error {
+	a.file.Close()
+	a.file = nil
+
+	os.Remove(a.rotatedPath(a.maxFiles))
+	for n := a.maxFiles - 1; n >= 1; n-- {
+		if err := os.Rename(a.rotatedPath(n), a.rotatedPath(n+1)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if err := os.Rename(a.path, a.rotatedPath(1)); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return a.open()
+}
+
+func (a *auditLog) rotatedPath(n int) string {
+	return fmt.Sprintf("%s.%d", a.path, n)
+}
+
+// close closes the current file
+func (a *auditLog) close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+	err := a.file.Close()
+	a.file = nil
+	return err
+}
+
+// query returns matching entries, newest first, reading rotated files as needed
+func (a *auditLog) query(q AuditQuery) ([]AuditEntry, error) {
+	if q.Limit <= 0 {
+		q.Limit = 100
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entries := []AuditEntry{}
+	for n := 0; n <= a.maxFiles && len(entries) < q.Limit; n++ {
+		path := a.path
+		if n > 0 {
+			path = a.rotatedPath(n)
+		}
+		data, err := os.ReadFile(path)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return entries, err
+		}
+
+		var lines [][]byte
+		scanner := bufio.NewScanner(bytes.NewReader(data))
+		scanner.Buffer(nil, len(data)+1)
+		for scanner.Scan() {
+			lines = append(lines, scanner.Bytes())
+		}
+
+		for i := len(lines) - 1; i >= 0 && len(entries) < q.Limit; i-- {
+			var entry AuditEntry
+			if json.Unmarshal(lines[i], &entry) != nil {
+				continue // Torn write
+			}
+			if !q.Since.IsZero() && entry.Time.Before(q.Since) {
+				return entries, nil // Older entries only from here on
+			}
+			if (q.Tool == "" || entry.Tool == q.Tool) && (q.Session == "" || entry.Session == q.Session) {
+				entries = append(entries, entry)
+			}
+		}
+	}
+	return entries, nil
+}
+
+// AuditEntries returns the most recent audit log entries matching q, newest first.
+// It returns an error when Config.AuditLogPath is not set.
+func (h *Handler) AuditEntries(q AuditQuery) ([]AuditEntry, error) {
+	if h.audit == nil {
+		return nil, fmt.Errorf("audit log disabled: set Config.AuditLogPath")
+	}
+	return h.audit.query(q)
+}
+
+// auditToolCall records one finished tools/call
+func (h *Handler) auditToolCall(ctx context.Context, tool string, args map[string]any, result *mcp.CallToolResult, elapsed time.Duration, panicked bool) {
+	if h.audit == nil {
+		return
+	}
+
+	entry := AuditEntry{
+		Time:       time.Now().UTC(),
+		Session:    sessionID(ctx),
+		Client:     clientName(ctx),
+		Tool:       tool,
+		Outcome:    callOutcome(result, panicked),
+		DurationMs: float64(elapsed.Microseconds()) / 1000,
+		OutputSize: resultSize(result),
+	}
+	entry.Args = redactArgs(args, func(name string) bool {
+		return h.isSensitive(tool, name) || h.audit.sensitive(name)
+	})
+	if result != nil && result.IsError && len(result.Content) > 0 {
+		if text, ok := result.Content[0].(mcp.TextContent); ok {
+			entry.Error = text.Text
+		}
+	}
+
+	if err := h.audit.write(entry); err != nil {
+		h.log("Warning: could not write audit log:", err)
+	}
+}
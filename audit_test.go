@@ -0,0 +1,108 @@
+package mcpserve
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestAuditToolCall verifies entries, redaction and queries
+func TestAuditToolCall(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "audit", "mcp.jsonl")
+	handler := NewHandler(Config{AuditLogPath: path, AuditRedact: []string{"Cookie"}}, nil, &mockTUI{})
+
+	call := func(name string, args map[string]any, executor ToolExecutor) {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		req.Params.Arguments = args
+		handler.mcpExecuteTool(&mockHandler{}, executor, nil)(context.Background(), req)
+	}
+	call("deploy", map[string]any{"target": "prod", "apiToken": "abc", "session_cookie": "xyz"}, func(map[string]any) {})
+	call("build", nil, func(map[string]any) { panic("boom") })
+
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected 0600, got %v", info.Mode().Perm())
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "abc") || strings.Contains(string(data), "xyz") {
+		t.Errorf("Sensitive values leaked into the audit log:\n%s", data)
+	}
+
+	entries, err := handler.AuditEntries(AuditQuery{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 2 || entries[0].Tool != "build" || entries[1].Tool != "deploy" {
+		t.Fatalf("Expected newest first, got %+v", entries)
+	}
+	if entries[0].Outcome != "panic" || entries[0].Error != "tool build panicked: boom" {
+		t.Errorf("Unexpected panic entry %+v", entries[0])
+	}
+	deploy := entries[1]
+	if deploy.Outcome != "ok" || deploy.OutputSize != len("Operation completed successfully") {
+		t.Errorf("Unexpected deploy entry %+v", deploy)
+	}
+	if deploy.Args["target"] != "prod" || deploy.Args["apiToken"] != redactedValue || deploy.Args["session_cookie"] != redactedValue {
+		t.Errorf("Unexpected args %v", deploy.Args)
+	}
+
+	entries, _ = handler.AuditEntries(AuditQuery{Tool: "deploy"})
+	if len(entries) != 1 {
+		t.Errorf("Expected 1 deploy entry, got %d", len(entries))
+	}
+	entries, _ = handler.AuditEntries(AuditQuery{Since: time.Now().Add(time.Hour)})
+	if len(entries) != 0 {
+		t.Errorf("Expected no entry in the future, got %d", len(entries))
+	}
+
+	if _, err := NewHandler(Config{}, nil, nil).AuditEntries(AuditQuery{}); err == nil {
+		t.Error("Expected an error with the audit log disabled")
+	}
+}
+
+// TestAuditRotation verifies size-based rotation and queries across rotated files
+func TestAuditRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "mcp.jsonl")
+	audit := newAuditLog(Config{AuditLogPath: path, AuditMaxSize: 200, AuditMaxFiles: 2})
+	defer audit.close()
+
+	for i := 0; i < 20; i++ {
+		if err := audit.write(AuditEntry{Time: time.Now(), Tool: "tool", OutputSize: i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, p := range []string{path, path + ".1", path + ".2"} {
+		info, err := os.Stat(p)
+		if err != nil {
+			t.Fatalf("Missing %s: %v", p, err)
+		}
+		if info.Size() > 200 {
+			t.Errorf("%s exceeds the max size: %d", p, info.Size())
+		}
+	}
+	if _, err := os.Stat(path + ".3"); !os.IsNotExist(err) {
+		t.Error("Only 2 rotated files should be kept")
+	}
+
+	entries, err := audit.query(AuditQuery{Limit: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 5 || entries[0].OutputSize != 19 || entries[4].OutputSize != 15 {
+		t.Errorf("Expected the 5 newest entries, got %+v", entries)
+	}
+	all, _ := audit.query(AuditQuery{Limit: 100})
+	if len(all) >= 20 || all[len(all)-1].OutputSize == 0 {
+		t.Errorf("Expected the oldest entries to be rotated out, got %d", len(all))
+	}
+}
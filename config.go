@@ -4,81 +4,167 @@ import (
 	"encoding/json"
 	"fmt"
 	"os"
+	"reflect"
 	"strings"
 )
 
-// mcpConfig represents the structure of VS Code's mcp.json file
-type mcpConfig struct {
-	Servers map[string]mcpServerConfig `json:"servers"`
-	Inputs  []any                      `json:"inputs"`
-}
+// newVSCodeMCPConfig is the content of a freshly created VS Code mcp.json file
+const newVSCodeMCPConfig = "{\n\t\"servers\": {},\n\t\"inputs\": []\n}"
 
 // mcpServerConfig represents a single MCP server configuration
+// Each IDE uses the subset of fields its schema understands
 type mcpServerConfig struct {
 	URL       string   `json:"url,omitempty"`
-	Type      string   `json:"type"`
+	ServerURL string   `json:"serverUrl,omitempty"` // Windsurf
+	Type      string   `json:"type,omitempty"`
 	Command   string   `json:"command,omitempty"`
 	Args      []string `json:"args,omitempty"`
 	AutoStart bool     `json:"autoStart,omitempty"` // Attempt to force auto-start
 }
 
-// updateMCPConfig reads, updates, and writes the mcp.json file.
-// Adds or updates the MCP server entry with the current configuration.
+// mcpServerID returns the name of this app's server entry in IDE configs
+func mcpServerID(appName string) string {
+	return fmt.Sprintf("%s-mcp", strings.ToLower(appName))
+}
+
+// mcpServerURL returns the endpoint IDEs connect to
+func mcpServerURL(mcpPort string) string {
+	return fmt.Sprintf("http://localhost:%s/mcp", mcpPort)
+}
+
+// updateMCPConfig reads, updates, and writes an IDE's MCP config file.
+// Adds or updates the MCP server entry under ide.ServersKey using the IDE's schema.
 // Creates new file if it doesn't exist.
-// Returns nil for permission errors (silent failure).
-func updateMCPConfig(configPath string, appName string, mcpPort string) error {
-	var config mcpConfig
+// Writes are atomic (temp file + rename), keep the original file mode, back up
+// the previous content and hold a lock so concurrent app instances don't clobber each other.
+// In dry-run mode nothing is written; the returned diff shows what would change.
+func updateMCPConfig(configPath string, ide IDEInfo, appName string, mcpPort string, dryRun bool) (IDEStatus, string, error) {
+	if !dryRun {
+		unlock, err := lockFile(configPath)
+		if err != nil {
+			return IDEFailed, "", err
+		}
+		defer unlock()
+	}
 
 	// Read existing config
-	data, err := os.ReadFile(configPath)
+	data, perm, err := readConfigFile(configPath)
+	if err != nil {
+		return IDEFailed, "", err
+	}
+
+	// Parse existing config (JSONC: comments and trailing commas are allowed)
+	source := data
+	if source == nil {
+		source = []byte(ide.NewFile)
+	}
+	doc, err := parseJSONC(source)
+	if err != nil {
+		return IDEFailed, "", fmt.Errorf("invalid JSON in %s: %w", configPath, err)
+	}
+
+	// Add/update MCP entry with app-specific name, leaving everything else untouched
+	serverID := mcpServerID(appName)
+	entry := ide.Entry(mcpServerURL(mcpPort))
+
+	// Nothing to do when the entry is already up to date
+	if current, ok := doc.Get(ide.ServersKey, serverID); ok && data != nil && sameJSON(current, entry) {
+		return IDEUnchanged, "", nil
+	}
+
+	if err := doc.Set([]string{ide.ServersKey, serverID}, entry); err != nil {
+		return IDEFailed, "", fmt.Errorf("updating %s: %w", configPath, err)
+	}
+
+	status := IDEUpdated
+	if data == nil {
+		status = IDECreated
+	}
+	diff := unifiedDiff(configPath, data, doc.Bytes())
+
+	if dryRun {
+		return status, diff, nil
+	}
+	if err := writeConfigFile(configPath, data, doc.Bytes(), perm); err != nil {
+		return IDEFailed, diff, err
+	}
+	return status, diff, nil
+}
+
+// removeMCPConfigEntry deletes serverID from the config file, but only while the entry
+// still matches what we wrote, so entries edited by the user are left alone.
+// Reports whether the entry was removed.
+func removeMCPConfigEntry(configPath string, serversKey string, serverID string, written json.RawMessage) (bool, error) {
+	unlock, err := lockFile(configPath)
 	if err != nil {
 		if os.IsNotExist(err) {
-			// Create new config structure
-			config = mcpConfig{
-				Servers: make(map[string]mcpServerConfig),
-				Inputs:  []any{},
-			}
-		} else if os.IsPermission(err) {
-			// No permissions, return silently
-			return nil
-		} else {
-			return err
-		}
-	} else {
-		// Parse existing config
-		if err := json.Unmarshal(data, &config); err != nil {
-			// Invalid JSON, fail silently
-			return nil
+			return false, nil // Folder is gone, and the entry with it
 		}
+		return false, err
+	}
+	defer unlock()
 
-		if config.Servers == nil {
-			config.Servers = make(map[string]mcpServerConfig)
-		}
-		if config.Inputs == nil {
-			config.Inputs = []any{}
-		}
+	data, perm, err := readConfigFile(configPath)
+	if err != nil || data == nil {
+		return false, err
 	}
 
-	// Add/update MCP entry with app-specific name
-	serverID := fmt.Sprintf("%s-mcp", strings.ToLower(appName))
-	config.Servers[serverID] = mcpServerConfig{
-		URL:  fmt.Sprintf("http://localhost:%s/mcp", mcpPort),
-		Type: "http",
+	doc, err := parseJSONC(data)
+	if err != nil {
+		return false, fmt.Errorf("invalid JSON in %s: %w", configPath, err)
+	}
+
+	current, ok := doc.Get(serversKey, serverID)
+	if !ok || !sameJSON(current, written) {
+		return false, nil
 	}
 
-	// Marshal with proper formatting (tabs for consistency with VS Code)
-	updatedData, err := json.MarshalIndent(config, "", "\t")
+	if _, err := doc.Delete(serversKey, serverID); err != nil {
+		return false, fmt.Errorf("updating %s: %w", configPath, err)
+	}
+
+	return true, writeConfigFile(configPath, data, doc.Bytes(), perm)
+}
+
+// readConfigFile returns the file content and mode; data is nil when the file doesn't exist
+func readConfigFile(configPath string) (data []byte, perm os.FileMode, err error) {
+	perm = 0644
+
+	data, err = os.ReadFile(configPath)
+	if os.IsNotExist(err) {
+		return nil, perm, nil
+	}
 	if err != nil {
-		return err
+		return nil, perm, err
 	}
 
-	// Write back (fail silently on permission errors)
-	if err := os.WriteFile(configPath, updatedData, 0644); err != nil {
-		if os.IsPermission(err) {
-			return nil // Silent failure
+	if info, err := os.Stat(configPath); err == nil {
+		perm = info.Mode().Perm()
+	}
+	return data, perm, nil
+}
+
+// writeConfigFile backs up the previous content (if any) and atomically writes the new one
+func writeConfigFile(configPath string, previous, updated []byte, perm os.FileMode) error {
+	// Keep a timestamped copy of the previous file
+	if previous != nil {
+		if err := backupFile(configPath, previous, perm); err != nil {
+			return err
 		}
-		return err
 	}
 
-	return nil
+	return writeFileAtomic(configPath, updated, perm)
+}
+
+// sameJSON reports whether raw encodes the same value as v, ignoring formatting and key order
+func sameJSON(raw json.RawMessage, v any) bool {
+	encoded, err := json.Marshal(v)
+	if err != nil {
+		return false
+	}
+	var a, b any
+	if json.Unmarshal(raw, &a) != nil || json.Unmarshal(encoded, &b) != nil {
+		return false
+	}
+	return reflect.DeepEqual(a, b)
 }
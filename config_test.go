@@ -0,0 +1,169 @@
+package mcpserve
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestUpdateMCPConfigAtomicWrite verifies mode preservation, backups and lock cleanup
+func TestUpdateMCPConfigAtomicWrite(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "mcp.json")
+	original := "{\n\t\"servers\": {},\n\t\"inputs\": []\n}"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := updateMCPConfig(configPath, defaultIDEs()[0], "TinyWasm", "3030", false); err != nil {
+		t.Fatalf("updateMCPConfig failed: %v", err)
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), `"tinywasm-mcp"`) || !strings.Contains(string(data), "http://localhost:3030/mcp") {
+		t.Errorf("Server entry not written:\n%s", data)
+	}
+
+	if runtime.GOOS != "windows" {
+		info, _ := os.Stat(configPath)
+		if info.Mode().Perm() != 0600 {
+			t.Errorf("Expected mode 0600 to be preserved, got %v", info.Mode().Perm())
+		}
+	}
+
+	backups, _ := filepath.Glob(configPath + ".bak-*")
+	if len(backups) != 1 {
+		t.Fatalf("Expected 1 backup, got %v", backups)
+	}
+	if backup, _ := os.ReadFile(backups[0]); string(backup) != original {
+		t.Errorf("Backup does not hold the previous content:\n%s", backup)
+	}
+
+	if _, err := os.Stat(configPath + ".lock"); !os.IsNotExist(err) {
+		t.Error("Lock file was not removed")
+	}
+
+	// Re-running with the same settings leaves the file alone
+	if _, _, err := updateMCPConfig(configPath, defaultIDEs()[0], "TinyWasm", "3030", false); err != nil {
+		t.Fatalf("Second update failed: %v", err)
+	}
+	if again, _ := filepath.Glob(configPath + ".bak-*"); len(again) != 1 {
+		t.Errorf("Unchanged config should not create another backup, got %v", again)
+	}
+}
+
+// TestUpdateMCPConfigSymlink verifies a symlinked config is updated through the link, which is kept
+func TestUpdateMCPConfigSymlink(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("Symlinks need extra privileges on Windows")
+	}
+	dotfiles := filepath.Join(t.TempDir(), "settings.json")
+	os.WriteFile(dotfiles, []byte("{}"), 0644)
+	configPath := filepath.Join(t.TempDir(), "settings.json")
+	if err := os.Symlink(dotfiles, configPath); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, port := range []string{"3030", "3031"} { // Twice within the same second
+		if _, _, err := updateMCPConfig(configPath, defaultIDEs()[0], "TinyWasm", port, false); err != nil {
+			t.Fatalf("updateMCPConfig failed: %v", err)
+		}
+	}
+
+	if info, err := os.Lstat(configPath); err != nil || info.Mode()&os.ModeSymlink == 0 {
+		t.Errorf("The symlink was replaced by a regular file")
+	}
+	if data := readTestFile(t, dotfiles); !strings.Contains(data, "http://localhost:3031/mcp") {
+		t.Errorf("Link target not updated:\n%s", data)
+	}
+	if backups, _ := filepath.Glob(configPath + ".bak-*"); len(backups) != 2 {
+		t.Errorf("Expected a backup per write, got %v", backups)
+	}
+}
+
+// TestUpdateMCPConfigInvalidJSON verifies broken files are reported and left untouched
+func TestUpdateMCPConfigInvalidJSON(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp.json")
+	broken := `{"servers": {`
+	os.WriteFile(configPath, []byte(broken), 0644)
+
+	if _, _, err := updateMCPConfig(configPath, defaultIDEs()[0], "TinyWasm", "3030", false); err == nil {
+		t.Error("Expected an error for invalid JSON")
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != broken {
+		t.Errorf("Invalid file should not be modified, got:\n%s", data)
+	}
+}
+
+// TestLockFileExclusive verifies a held lock blocks others and stale locks are broken
+func TestLockFileExclusive(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "mcp.json")
+
+	unlock, err := lockFile(configPath)
+	if err != nil {
+		t.Fatalf("lockFile failed: %v", err)
+	}
+	if _, err := os.Stat(configPath + ".lock"); err != nil {
+		t.Fatalf("Lock file missing: %v", err)
+	}
+	unlock()
+
+	// Simulate a lock left behind by a crashed process
+	os.WriteFile(configPath+".lock", []byte("1\n"), 0644)
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	os.Chtimes(configPath+".lock", stale, stale)
+
+	unlock, err = lockFile(configPath)
+	if err != nil {
+		t.Fatalf("Stale lock was not broken: %v", err)
+	}
+	unlock()
+}
+
+// TestBreakStaleLockRace verifies a waiter that saw a stale lock leaves the fresh lock of another one alone
+func TestBreakStaleLockRace(t *testing.T) {
+	dir := t.TempDir()
+	lockPath := filepath.Join(dir, "mcp.json.lock")
+
+	// Another waiter already broke the stale lock and holds a fresh one
+	writeTestFile(t, lockPath, "42\n")
+	breakStaleLock(lockPath)
+	if got := readTestFile(t, lockPath); got != "42\n" {
+		t.Errorf("Fresh lock should be kept, got %q", got)
+	}
+
+	// A stale lock is removed
+	stale := time.Now().Add(-2 * lockStaleAfter)
+	os.Chtimes(lockPath, stale, stale)
+	breakStaleLock(lockPath)
+	if _, err := os.Stat(lockPath); !os.IsNotExist(err) {
+		t.Errorf("Stale lock should be removed, got %v", err)
+	}
+
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("Expected no leftover files, got %v", entries)
+	}
+}
+
+func writeTestFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func readTestFile(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
@@ -0,0 +1,126 @@
+package mcpserve
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext is the number of unchanged lines shown around each change
+const diffContext = 2
+
+// unifiedDiff returns a unified diff between before and after, or "" when they are equal.
+// Config files are small, so a plain LCS table is good enough.
+func unifiedDiff(path string, before, after []byte) string {
+	if string(before) == string(after) {
+		return ""
+	}
+	a := splitLines(string(before))
+	b := splitLines(string(after))
+
+	// lcs[i][j] is the longest common subsequence of a[i:] and b[j:]
+	lcs := make([][]int, len(a)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			if a[i] == b[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	// Walk the table into a list of ' ', '-' and '+' lines
+	type diffLine struct {
+		op   byte
+		text string
+	}
+	lines := []diffLine{}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			lines = append(lines, diffLine{' ', a[i]})
+			i++
+			j++
+		case i < len(a) && (j == len(b) || lcs[i+1][j] >= lcs[i][j+1]):
+			// Deletions first, so a changed line reads as - then +
+			lines = append(lines, diffLine{'-', a[i]})
+			i++
+		default:
+			lines = append(lines, diffLine{'+', b[j]})
+			j++
+		}
+	}
+
+	// Keep changes plus diffContext lines around them
+	keep := make([]bool, len(lines))
+	for n, line := range lines {
+		if line.op == ' ' {
+			continue
+		}
+		for k := max(0, n-diffContext); k <= min(len(lines)-1, n+diffContext); k++ {
+			keep[k] = true
+		}
+	}
+
+	var out strings.Builder
+	fmt.Fprintf(&out, "--- %s\n+++ %s\n", path, path)
+
+	// Emit each run of kept lines as a hunk
+	oldLine, newLine := 1, 1 // Line numbers of lines[n] in a and b
+	for n := 0; n < len(lines); {
+		if !keep[n] {
+			if lines[n].op != '+' {
+				oldLine++
+			}
+			if lines[n].op != '-' {
+				newLine++
+			}
+			n++
+			continue
+		}
+
+		end := n
+		oldCount, newCount := 0, 0
+		for ; end < len(lines) && keep[end]; end++ {
+			if lines[end].op != '+' {
+				oldCount++
+			}
+			if lines[end].op != '-' {
+				newCount++
+			}
+		}
+
+		fmt.Fprintf(&out, "@@ -%d,%d +%d,%d @@\n", hunkStart(oldLine, oldCount), oldCount, hunkStart(newLine, newCount), newCount)
+		for _, line := range lines[n:end] {
+			out.WriteByte(line.op)
+			out.WriteString(line.text)
+			out.WriteByte('\n')
+		}
+
+		oldLine += oldCount
+		newLine += newCount
+		n = end
+	}
+	return strings.TrimSuffix(out.String(), "\n")
+}
+
+// hunkStart returns the start line of a hunk side: an empty side starts at the line before it,
+// e.g. -0,0 for a new file
+func hunkStart(line, count int) int {
+	if count == 0 {
+		return line - 1
+	}
+	return line
+}
+
+// splitLines splits text into lines without their terminators
+func splitLines(text string) []string {
+	if text == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+}
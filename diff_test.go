@@ -0,0 +1,41 @@
+package mcpserve
+
+import "testing"
+
+// TestUnifiedDiff verifies line order within changes and hunk headers of empty sides
+func TestUnifiedDiff(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after string
+		want          string
+	}{
+		{
+			name:   "changed line",
+			before: "a\nb\nc\n",
+			after:  "a\nB\nc\n",
+			want:   "--- f\n+++ f\n@@ -1,3 +1,3 @@\n a\n-b\n+B\n c",
+		},
+		{
+			name:  "new file",
+			after: "a\nb\n",
+			want:  "--- f\n+++ f\n@@ -0,0 +1,2 @@\n+a\n+b",
+		},
+		{
+			name:   "emptied file",
+			before: "a\n",
+			want:   "--- f\n+++ f\n@@ -1,1 +0,0 @@\n-a",
+		},
+		{
+			name:   "unchanged",
+			before: "a\n",
+			after:  "a\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := unifiedDiff("f", []byte(tt.before), []byte(tt.after)); got != tt.want {
+				t.Errorf("got\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package mcpserve
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// errShuttingDown is returned to clients calling a tool while the server drains
+const errShuttingDown = "server shutting down"
+
+// ShutdownError is returned by Shutdown when tool executions were still running at the deadline.
+// Executors cannot be cancelled, so they keep running in the background; their results are lost.
+type ShutdownError struct {
+	Interrupted []string // Tool names, one entry per interrupted execution
+	Err         error    // Usually context.DeadlineExceeded
+}
+
+func (e *ShutdownError) Error() string {
+	return fmt.Sprintf("shutdown interrupted %d tool calls (%s): %v",
+		len(e.Interrupted), strings.Join(e.Interrupted, ", "), e.Err)
+}
+
+func (e *ShutdownError) Unwrap() error {
+	return e.Err
+}
+
+// callTracker records in-flight tool executions so shutdown can drain them.
+// Once draining, new executions are refused.
+type callTracker struct {
+	mu       sync.Mutex
+	running  map[uint64]string // Execution ID -> tool name
+	nextID   uint64
+	draining bool
+	idle     chan struct{} // Closed whenever no execution is running
+}
+
+func newCallTracker() *callTracker {
+	idle := make(chan struct{})
+	close(idle)
+	return &callTracker{running: map[uint64]string{}, idle: idle}
+}
+
+// begin registers an execution of tool; the returned func must be called when it ends.
+// ok is false when the tracker is draining and the execution must not start.
+func (t *callTracker) begin(tool string) (end func(), ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.draining {
+		return func() {}, false
+	}
+	if len(t.running) == 0 {
+		t.idle = make(chan struct{})
+	}
+	id := t.nextID
+	t.nextID++
+	t.running[id] = tool
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			delete(t.running, id)
+			if len(t.running) == 0 {
+				close(t.idle)
+			}
+		})
+	}, true
+}
+
+// drain refuses new executions from now on
+func (t *callTracker) drain() {
+	t.mu.Lock()
+	t.draining = true
+	t.mu.Unlock()
+}
+
+// count returns the number of executions in flight
+func (t *callTracker) count() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.running)
+}
+
+// tools returns the sorted names of the tools in flight, one entry per execution
+func (t *callTracker) tools() []string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	names := make([]string, 0, len(t.running))
+	for _, name := range t.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// wait blocks until no execution is in flight or ctx is done
+func (t *callTracker) wait(ctx context.Context) error {
+	t.mu.Lock()
+	idle := t.idle
+	t.mu.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		if t.count() == 0 {
+			return nil // Finished right at the deadline
+		}
+		return ctx.Err()
+	}
+}
+
+// connTracker records connections that have not sent a request yet. http.Server.Shutdown only
+// treats those as idle after 5s, so clients that pre-dial connections would stall shutdown.
+type connTracker struct {
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+// track is an http.Server.ConnState hook
+func (t *connTracker) track(conn net.Conn, state http.ConnState) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if state == http.StateNew {
+		if t.conns == nil {
+			t.conns = map[net.Conn]struct{}{}
+		}
+		t.conns[conn] = struct{}{}
+		return
+	}
+	delete(t.conns, conn)
+}
+
+// closeUnused closes the connections that never sent a request
+func (t *connTracker) closeUnused() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for conn := range t.conns {
+		conn.Close()
+	}
+	clear(t.conns)
+}
+
+// streamCloser ends the GET streams stateful sessions keep open for server-to-client messages.
+// http.Server.Shutdown waits for every request, so an idle listening client would hold it until the deadline.
+type streamCloser struct {
+	ctx   context.Context
+	close context.CancelFunc
+}
+
+func newStreamCloser() *streamCloser {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &streamCloser{ctx: ctx, close: cancel}
+}
+
+// wrap cancels the context of GET requests to next once close is called
+func (s *streamCloser) wrap(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			next.ServeHTTP(w, r)
+			return
+		}
+		ctx, cancel := context.WithCancel(r.Context())
+		defer cancel()
+		defer context.AfterFunc(s.ctx, cancel)()
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
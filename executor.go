@@ -4,9 +4,12 @@ import (
 	"context"
 	"encoding/base64"
 	"fmt"
+	"runtime/debug"
 	"strings"
+	"time"
 
 	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
 )
 
 // BinaryData represents binary response from tools (imported from handlers)
@@ -15,20 +18,88 @@ type BinaryData struct {
 	Data     []byte
 }
 
-// mcpExecuteTool creates a GENERIC tool executor that works for ANY handler tool
-// It extracts args, collects progress, executes the tool, and returns results
-// NO domain-specific logic here - handlers provide their own Execute functions
-// mcpExecuteTool creates a GENERIC tool executor that works for ANY handler tool
-// It extracts args, collects logs via SetLog, executes the tool, and returns results
-func (h *Handler) mcpExecuteTool(targetHandler any, executor ToolExecutor) func(context.Context, mcp.CallToolRequest) (*mcp.CallToolResult, error) {
-	return func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+// toolCall runs one tools/call once instrumentTool has admitted it.
+// secrets are the sensitive argument values to mask in output.
+type toolCall func(ctx context.Context, req mcp.CallToolRequest, args map[string]any, secrets []string) *mcp.CallToolResult
+
+// instrumentTool wraps a tool implementation with what every tools/call goes through, local or proxied:
+// drain on shutdown, rate limits, tracing, metrics, audit, activity events and panic recovery
+func (h *Handler) instrumentTool(call toolCall) server.ToolHandlerFunc {
+	return func(ctx context.Context, req mcp.CallToolRequest) (result *mcp.CallToolResult, err error) {
 		// 1. Extract arguments (generic)
 		args, ok := req.Params.Arguments.(map[string]any)
 		if !ok {
 			args = make(map[string]any)
 		}
 
-		// 2. Setup capturing logger if handler is Loggable
+		// Trace, audit and record metrics for every call, and turn executor panics into error results
+		began := time.Now()
+		secrets := h.secretValues(req.Params.Name, args) // Masked wherever output is returned or logged
+		ctx, span := h.startToolSpan(ctx, req, args)
+		h.emitActivity(ctx, ActivityEvent{Kind: ActivityToolStarted, Tool: req.Params.Name})
+		defer func() {
+			panicked := false
+			if r := recover(); r != nil {
+				panicked = true
+				reason := h.scrub(fmt.Sprint(r), secrets)
+				h.log(fmt.Sprintf("Tool %s panicked: %s\n%s", req.Params.Name, reason, debug.Stack()))
+				result, err = mcp.NewToolResultError(fmt.Sprintf("tool %s panicked: %s", req.Params.Name, reason)), nil
+			}
+			elapsed := time.Since(began)
+			h.metrics.record(req.Params.Name, elapsed, result != nil && result.IsError, panicked)
+			h.auditToolCall(ctx, req.Params.Name, args, result, elapsed, panicked)
+			h.emitActivity(ctx, ActivityEvent{
+				Kind:     ActivityToolFinished,
+				Tool:     req.Params.Name,
+				Duration: elapsed,
+				Status:   callOutcome(result, panicked),
+			})
+			if panicked && span != nil {
+				span.SetAttribute("mcp.tool.panic", true)
+			}
+			endToolSpan(span, result)
+		}()
+
+		// Tracked so Shutdown can drain in-flight executions; refused once it has begun
+		end, accepted := h.calls.begin(req.Params.Name)
+		defer end()
+		if !accepted {
+			return mcp.NewToolResultError(errShuttingDown), nil
+		}
+
+		// 2. Enforce rate limits and concurrency caps (reject, never queue)
+		release, err := h.limiter.acquire(req.Params.Name, sessionID(ctx))
+		if err != nil {
+			h.log(fmt.Sprintf("Tool %s: %v", req.Params.Name, err))
+			return mcp.NewToolResultError(err.Error()), nil
+		}
+		defer release()
+
+		return call(ctx, req, args, secrets), nil
+	}
+}
+
+// mcpExecuteTool creates a GENERIC tool executor that works for ANY handler tool
+// It collects logs via SetLog, executes the tool, and returns results
+// NO domain-specific logic here - handlers provide their own Execute functions
+// A non-nil queue serializes executions with the other tools of the same handler
+func (h *Handler) mcpExecuteTool(targetHandler any, executor ToolExecutor, queue *handlerQueue) server.ToolHandlerFunc {
+	return h.instrumentTool(func(ctx context.Context, req mcp.CallToolRequest, args map[string]any, secrets []string) *mcp.CallToolResult {
+		// 3. Wait for our turn on serialized handlers
+		var queueWait time.Duration
+		queued := false
+		if queue != nil {
+			start := time.Now()
+			leave, waited, err := queue.enter(ctx)
+			if err != nil {
+				h.log(fmt.Sprintf("Tool %s: %v", req.Params.Name, err))
+				return mcp.NewToolResultError(err.Error())
+			}
+			defer leave()
+			queueWait, queued = time.Since(start), waited
+		}
+
+		// 4. Setup capturing logger if handler is Loggable
 		messages := []string{}
 		var binaryResponse *BinaryData
 
@@ -53,29 +124,76 @@ func (h *Handler) mcpExecuteTool(targetHandler any, executor ToolExecutor) func(
 			})
 		}
 
-		// 3. Execute handler-specific logic
+		// Sampling goes to the client that made this call
+		h.injectSampler(targetHandler, ctx)
+
+		// 5. Execute handler-specific logic
 		executor(args)
 
-		// 4. Refresh UI (generic)
-		if h.tui != nil {
-			h.tui.RefreshUI()
+		// 6. Refresh UI (generic, coalesced), only the handler's section when the TUI supports it
+		if tool, _ := h.tool(req.Params.Name); !tool.ReadOnly {
+			h.refresh.request(handlerName(targetHandler))
 		}
 
-		// 5. Handle binary response (if present) - prioritize over text
+		// 7. Handle binary response (if present) - prioritize over text
 		if binaryResponse != nil {
 			base64Data := base64.StdEncoding.EncodeToString(binaryResponse.Data)
+			if queued {
+				messages = append(messages, queueWaitNote(queueWait))
+			}
 			textSummary := ""
 			if len(messages) > 0 {
-				textSummary = strings.Join(messages, "\n")
+				textSummary = h.scrub(strings.Join(messages, "\n"), secrets)
 			}
-			return mcp.NewToolResultImage(textSummary, base64Data, binaryResponse.MimeType), nil
+			return withQueueWait(mcp.NewToolResultImage(textSummary, base64Data, binaryResponse.MimeType), queue, queueWait)
 		}
 
-		// 6. Return text messages (if no binary)
+		// 8. Return text messages (if no binary)
 		if len(messages) == 0 {
-			return mcp.NewToolResultText("Operation completed successfully"), nil
+			messages = append(messages, "Operation completed successfully")
+		}
+		if queued {
+			messages = append(messages, queueWaitNote(queueWait))
 		}
 
-		return mcp.NewToolResultText(strings.Join(messages, "\n")), nil
+		return withQueueWait(mcp.NewToolResultText(h.scrub(strings.Join(messages, "\n"), secrets)), queue, queueWait)
+	})
+}
+
+// withQueueWait records the queue wait of serialized executions in the result metadata
+func withQueueWait(result *mcp.CallToolResult, queue *handlerQueue, wait time.Duration) *mcp.CallToolResult {
+	if queue == nil {
+		return result
+	}
+	result.Meta = mcp.NewMetaFromMap(map[string]any{"queueWaitMs": wait.Milliseconds()})
+	return result
+}
+
+// clientName returns "name/version" of the MCP client from ctx, or "" when unknown
+func clientName(ctx context.Context) string {
+	if session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo); ok {
+		if info := session.GetClientInfo(); info.Name != "" {
+			return info.Name + "/" + info.Version
+		}
+	}
+	return ""
+}
+
+// callOutcome classifies a finished tool call as "ok", "error" or "panic"
+func callOutcome(result *mcp.CallToolResult, panicked bool) string {
+	switch {
+	case panicked:
+		return "panic"
+	case result == nil || result.IsError:
+		return "error"
+	}
+	return "ok"
+}
+
+// sessionID returns the MCP client session ID from ctx, or "" when sessions are not in use
+func sessionID(ctx context.Context) string {
+	if session := server.ClientSessionFromContext(ctx); session != nil {
+		return session.SessionID()
 	}
+	return ""
 }
@@ -0,0 +1,255 @@
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultDownstreamTimeout bounds connecting to a downstream server and listing its capabilities
+const defaultDownstreamTimeout = 10 * time.Second
+
+// Downstream is a third-party MCP server whose tools, resources and prompts are re-exposed
+// through our /mcp endpoint, so IDEs only need to register this server
+type Downstream struct {
+	Prefix  string   // Required namespace of its tools, prompts and resource names, e.g. "git" -> "git_log"
+	Command string   // Stdio server to launch, e.g. "uvx"
+	Args    []string // Arguments of Command, e.g. "mcp-server-git"
+	Env     []string // Extra "KEY=value" variables for Command
+	URL     string   // Streamable HTTP endpoint, used when Command is empty
+}
+
+// String names the downstream in logs
+func (d Downstream) String() string {
+	if d.Command != "" {
+		return fmt.Sprintf("%s (%s)", d.Prefix, d.Command)
+	}
+	return fmt.Sprintf("%s (%s)", d.Prefix, d.URL)
+}
+
+// check reports what is wrong with the configuration of d, or ""
+func (d Downstream) check() string {
+	if problem := checkToolName(d.Prefix); d.Prefix == "" || problem != "" {
+		return fmt.Sprintf("invalid Prefix %q", d.Prefix)
+	}
+	if d.Command == "" && d.URL == "" {
+		return "set Command or URL"
+	}
+	return ""
+}
+
+// connectDownstreams connects to every Config.Downstreams server and registers what it exposes.
+// A server that fails is left out; the errors are returned joined.
+func (h *Handler) connectDownstreams(ctx context.Context) error {
+	timeout := h.config.DownstreamTimeout
+	if timeout <= 0 {
+		timeout = defaultDownstreamTimeout
+	}
+
+	var errs []error
+	for _, d := range h.config.Downstreams {
+		connectCtx, cancel := context.WithTimeout(ctx, timeout)
+		c, err := h.connectDownstream(connectCtx, d)
+		cancel()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("downstream %s: %w", d, err))
+			continue
+		}
+
+		h.mu.Lock()
+		h.downstreams = append(h.downstreams, c)
+		h.mu.Unlock()
+	}
+	return errors.Join(errs...)
+}
+
+// connectDownstream starts a client for d, initializes it and registers its capabilities
+func (h *Handler) connectDownstream(ctx context.Context, d Downstream) (*client.Client, error) {
+	if problem := d.check(); problem != "" {
+		return nil, errors.New(problem)
+	}
+
+	var c *client.Client
+	var err error
+	switch {
+	case d.Command != "":
+		c, err = client.NewStdioMCPClient(d.Command, d.Env, d.Args...) // Starts the process
+	default:
+		c, err = client.NewStreamableHttpClient(d.URL)
+		if err == nil {
+			err = c.Start(ctx)
+		}
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: h.config.ServerName, Version: h.config.ServerVersion}
+	info, err := c.Initialize(ctx, init)
+	if err == nil {
+		err = h.registerDownstream(ctx, d, c, info.Capabilities)
+	}
+	if err != nil {
+		c.Close()
+		return nil, err
+	}
+	h.log(fmt.Sprintf("Connected downstream MCP server %s (%s %s)", d, info.ServerInfo.Name, info.ServerInfo.Version))
+	return c, nil
+}
+
+// registerDownstream re-exposes the tools, resources and prompts of a downstream server
+func (h *Handler) registerDownstream(ctx context.Context, d Downstream, c *client.Client, capabilities mcp.ServerCapabilities) error {
+	s := h.MCPServer()
+	sep, _ := h.namespaceSeparator()
+
+	if capabilities.Tools != nil {
+		tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+		if err != nil {
+			return err
+		}
+		for _, tool := range tools.Tools {
+			original := tool.Name
+			tool.Name = namespaced(d.Prefix, sep, original)
+			meta := ToolMetadata{Name: tool.Name, Description: tool.Description, Parameters: h.proxiedParameters(tool)}
+			if !h.addTool(meta) {
+				h.log(fmt.Sprintf("Warning: downstream %s tool %s conflicts with an existing tool, skipped", d, tool.Name))
+				continue
+			}
+			s.AddTool(tool, h.instrumentTool(h.proxyTool(d, c, original)))
+		}
+	}
+
+	if capabilities.Resources != nil {
+		resources, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+		if err != nil {
+			return err
+		}
+		for _, resource := range resources.Resources {
+			if !h.claimResource(d, resource.URI) {
+				continue
+			}
+			resource.Name = namespaced(d.Prefix, sep, resource.Name)
+			s.AddResource(resource, proxyResource(c))
+		}
+
+		templates, err := c.ListResourceTemplates(ctx, mcp.ListResourceTemplatesRequest{})
+		if err != nil {
+			return err
+		}
+		for _, template := range templates.ResourceTemplates {
+			if template.URITemplate == nil || !h.claimResource(d, template.URITemplate.Raw()) {
+				continue
+			}
+			template.Name = namespaced(d.Prefix, sep, template.Name)
+			s.AddResourceTemplate(template, server.ResourceTemplateHandlerFunc(proxyResource(c)))
+		}
+	}
+
+	if capabilities.Prompts != nil {
+		prompts, err := c.ListPrompts(ctx, mcp.ListPromptsRequest{})
+		if err != nil {
+			return err
+		}
+		for _, prompt := range prompts.Prompts {
+			original := prompt.Name
+			prompt.Name = namespaced(d.Prefix, sep, original)
+			s.AddPrompt(prompt, func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+				req.Params.Name = original
+				return c.GetPrompt(ctx, req)
+			})
+		}
+	}
+	return nil
+}
+
+// proxiedParameters lists the arguments of a downstream tool from its schema. Those named like
+// secrets (password, token... and Config.AuditRedact) are Sensitive, so their values are masked
+// in the output like those of local tools.
+func (h *Handler) proxiedParameters(tool mcp.Tool) []ParameterMetadata {
+	fragments := redactFragments(h.config)
+	var params []ParameterMetadata
+	for _, name := range slices.Sorted(maps.Keys(tool.InputSchema.Properties)) {
+		param := ParameterMetadata{
+			Name:      name,
+			Required:  slices.Contains(tool.InputSchema.Required, name),
+			Sensitive: matchesFragment(name, fragments),
+		}
+		if property, ok := tool.InputSchema.Properties[name].(map[string]any); ok {
+			param.Type, _ = property["type"].(string)
+			param.Description, _ = property["description"].(string)
+		}
+		params = append(params, param)
+	}
+	return params
+}
+
+// claimResource records that d serves uri. URIs are not prefixed, so when another downstream
+// already serves the same one it is logged and skipped instead of silently replacing it.
+func (h *Handler) claimResource(d Downstream, uri string) bool {
+	h.mu.Lock()
+	owner, taken := h.resources[uri]
+	if !taken {
+		if h.resources == nil {
+			h.resources = map[string]string{}
+		}
+		h.resources[uri] = d.String()
+	}
+	h.mu.Unlock()
+
+	if taken {
+		h.log(fmt.Sprintf("Warning: downstream %s resource %s is already served by %s, skipped", d, uri, owner))
+	}
+	return !taken
+}
+
+// proxyTool forwards a tools/call to the downstream tool called name, scrubbing its text output
+func (h *Handler) proxyTool(d Downstream, c *client.Client, name string) toolCall {
+	return func(ctx context.Context, req mcp.CallToolRequest, args map[string]any, secrets []string) *mcp.CallToolResult {
+		req.Params.Name = name
+		result, err := c.CallTool(ctx, req)
+		if err != nil {
+			return mcp.NewToolResultError(h.scrub(fmt.Sprintf("downstream %s: %v", d.Prefix, err), secrets))
+		}
+		for i, content := range result.Content {
+			if text, ok := content.(mcp.TextContent); ok {
+				text.Text = h.scrub(text.Text, secrets)
+				result.Content[i] = text
+			}
+		}
+		return result
+	}
+}
+
+// proxyResource forwards resources/read to the downstream server; URIs are kept as they are
+func proxyResource(c *client.Client) server.ResourceHandlerFunc {
+	return func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		result, err := c.ReadResource(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return result.Contents, nil
+	}
+}
+
+// closeDownstreams disconnects the downstream servers, stopping stdio processes
+func (h *Handler) closeDownstreams() {
+	h.mu.Lock()
+	downstreams := h.downstreams
+	h.downstreams = nil
+	h.mu.Unlock()
+
+	for _, c := range downstreams {
+		if err := c.Close(); err != nil {
+			h.log("Error closing downstream MCP server:", err)
+		}
+	}
+}
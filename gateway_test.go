@@ -0,0 +1,250 @@
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// The test binary doubles as a stdio downstream server
+func TestMain(m *testing.M) {
+	if os.Getenv("MCPSERVE_TEST_STDIO_SERVER") == "1" {
+		server.ServeStdio(newTestDownstream())
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// newTestDownstream is a third-party server with a tool, a resource and a prompt
+func newTestDownstream() *server.MCPServer {
+	s := server.NewMCPServer("git", "0.1.0")
+	s.AddTool(mcp.NewTool("log", mcp.WithString("ref")), func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+		return mcp.NewToolResultText("log of " + req.GetString("ref", "HEAD")), nil
+	})
+	s.AddResource(mcp.NewResource("file:///repo/README.md", "readme"), func(ctx context.Context, req mcp.ReadResourceRequest) ([]mcp.ResourceContents, error) {
+		return []mcp.ResourceContents{mcp.TextResourceContents{URI: req.Params.URI, Text: "# repo"}}, nil
+	})
+	s.AddPrompt(mcp.NewPrompt("commit"), func(ctx context.Context, req mcp.GetPromptRequest) (*mcp.GetPromptResult, error) {
+		return mcp.NewGetPromptResult("commit", []mcp.PromptMessage{
+			mcp.NewPromptMessage(mcp.RoleUser, mcp.NewTextContent("write a commit message")),
+		}), nil
+	})
+	return s
+}
+
+// gatewayClient starts a gateway with the given downstreams and connects to it in-process
+func gatewayClient(t *testing.T, downstreams ...Downstream) *client.Client {
+	t.Helper()
+	handler := NewHandler(Config{Port: "0", Downstreams: downstreams}, []any{&mockHandler{}}, nil)
+	startTestHandler(t, handler)
+	t.Cleanup(func() { handler.Shutdown(context.Background()) })
+
+	c, err := client.NewInProcessClient(handler.MCPServer())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// assertProxied checks the downstream tool, resource and prompt are reachable through the gateway
+func assertProxied(t *testing.T, c *client.Client) {
+	t.Helper()
+	ctx := context.Background()
+
+	tools, err := c.ListTools(ctx, mcp.ListToolsRequest{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{}
+	for _, tool := range tools.Tools {
+		names = append(names, tool.Name)
+	}
+	if strings.Join(names, ",") != "git_log,test_tool" {
+		t.Errorf("Expected local and proxied tools, got %v", names)
+	}
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "git_log"
+	req.Params.Arguments = map[string]any{"ref": "main"}
+	result, err := c.CallTool(ctx, req)
+	if err != nil || result.IsError || result.Content[0].(mcp.TextContent).Text != "log of main" {
+		t.Errorf("Unexpected proxied result %+v, %v", result, err)
+	}
+
+	resources, err := c.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil || len(resources.Resources) != 1 || resources.Resources[0].Name != "git_readme" {
+		t.Fatalf("Unexpected resources %+v, %v", resources, err)
+	}
+	read := mcp.ReadResourceRequest{}
+	read.Params.URI = resources.Resources[0].URI
+	contents, err := c.ReadResource(ctx, read)
+	if err != nil || contents.Contents[0].(mcp.TextResourceContents).Text != "# repo" {
+		t.Errorf("Unexpected resource contents %+v, %v", contents, err)
+	}
+
+	get := mcp.GetPromptRequest{}
+	get.Params.Name = "git_commit"
+	prompt, err := c.GetPrompt(ctx, get)
+	if err != nil || len(prompt.Messages) != 1 {
+		t.Errorf("Unexpected prompt %+v, %v", prompt, err)
+	}
+}
+
+func TestGatewayHTTP(t *testing.T) {
+	downstream := httptest.NewServer(server.NewStreamableHTTPServer(newTestDownstream()))
+	t.Cleanup(downstream.Close) // After the gateway has disconnected
+
+	assertProxied(t, gatewayClient(t, Downstream{Prefix: "git", URL: downstream.URL}))
+}
+
+func TestGatewayStdio(t *testing.T) {
+	assertProxied(t, gatewayClient(t, Downstream{
+		Prefix:  "git",
+		Command: os.Args[0],
+		Args:    []string{"-test.run=^$"},
+		Env:     []string{"MCPSERVE_TEST_STDIO_SERVER=1"},
+	}))
+}
+
+func TestGatewayDownstreamFailure(t *testing.T) {
+	handler := NewHandler(Config{Downstreams: []Downstream{
+		{Prefix: "none"},
+		{Prefix: "bad prefix", URL: "http://localhost:1/mcp"},
+	}}, nil, nil)
+
+	err := handler.connectDownstreams(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "set Command or URL") || !strings.Contains(err.Error(), `invalid Prefix "bad prefix"`) {
+		t.Errorf("Expected both downstreams to fail, got %v", err)
+	}
+
+	// Misconfigured downstreams are validation problems, so StrictTools refuses to start
+	got := []string{}
+	for _, problem := range handler.ValidateTools() {
+		got = append(got, problem.String())
+	}
+	want := `Config.Downstreams[0]: set Command or URL` + "\n" + `Config.Downstreams[1]: invalid Prefix "bad prefix"`
+	if strings.Join(got, "\n") != want {
+		t.Errorf("Unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+	strict := NewHandler(Config{Port: "0", StrictTools: true, Downstreams: []Downstream{{Prefix: "none"}}}, nil, nil)
+	var validationErr *ToolValidationError
+	if err := strict.Start(context.Background()); !errors.As(err, &validationErr) {
+		t.Errorf("Expected a validation error, got %v", err)
+	}
+}
+
+// inProcessClient connects to s in-process and returns its capabilities
+func inProcessClient(t *testing.T, s *server.MCPServer) (*client.Client, mcp.ServerCapabilities) {
+	t.Helper()
+	c, err := client.NewInProcessClient(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatal(err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	info, err := c.Initialize(ctx, init)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c, info.Capabilities
+}
+
+// TestGatewayRegisterDuringCalls verifies downstream tools can be registered while local tools run (run with -race)
+func TestGatewayRegisterDuringCalls(t *testing.T) {
+	handler := NewHandler(Config{}, []any{&mockHandler{}}, nil)
+	localTool := handler.MCPServer().GetTool("test_tool")
+	c, capabilities := inProcessClient(t, newTestDownstream())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "test_tool"
+		for i := 0; i < 50; i++ {
+			localTool.Handler(context.Background(), req)
+		}
+	}()
+	if err := handler.registerDownstream(context.Background(), Downstream{Prefix: "git"}, c, capabilities); err != nil {
+		t.Fatal(err)
+	}
+	<-done
+
+	if _, ok := handler.tool("git_log"); !ok {
+		t.Error("Downstream tool not registered")
+	}
+}
+
+// TestGatewayResourceConflict verifies a second downstream serving the same URI doesn't replace the first
+func TestGatewayResourceConflict(t *testing.T) {
+	handler := NewHandler(Config{}, nil, nil)
+	var logged []string
+	handler.SetLog(func(messages ...any) { logged = append(logged, fmt.Sprint(messages...)) })
+	ctx := context.Background()
+
+	for _, prefix := range []string{"git", "fs"} {
+		c, capabilities := inProcessClient(t, newTestDownstream())
+		if err := handler.registerDownstream(ctx, Downstream{Prefix: prefix, Command: prefix}, c, capabilities); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	gateway, _ := inProcessClient(t, handler.MCPServer())
+	resources, err := gateway.ListResources(ctx, mcp.ListResourcesRequest{})
+	if err != nil || len(resources.Resources) != 1 || resources.Resources[0].Name != "git_readme" {
+		t.Errorf("The first downstream should keep the URI, got %+v, %v", resources, err)
+	}
+	if !strings.Contains(strings.Join(logged, "\n"), "resource file:///repo/README.md is already served by git (git)") {
+		t.Errorf("Conflict not logged: %v", logged)
+	}
+}
+
+// TestGatewaySensitiveArguments verifies secret-looking arguments of proxied tools are masked in their output
+func TestGatewaySensitiveArguments(t *testing.T) {
+	downstream := server.NewMCPServer("auth", "0.1.0")
+	downstream.AddTool(mcp.NewTool("login", mcp.WithString("user"), mcp.WithString("api_token", mcp.Required())),
+		func(ctx context.Context, req mcp.CallToolRequest) (*mcp.CallToolResult, error) {
+			return mcp.NewToolResultText("logged in " + req.GetString("user", "") + " with " + req.GetString("api_token", "")), nil
+		})
+	handler := NewHandler(Config{}, nil, nil)
+	c, capabilities := inProcessClient(t, downstream)
+	if err := handler.registerDownstream(context.Background(), Downstream{Prefix: "auth"}, c, capabilities); err != nil {
+		t.Fatal(err)
+	}
+
+	meta, _ := handler.tool("auth_login")
+	if len(meta.Parameters) != 2 || !meta.Parameters[0].Sensitive || !meta.Parameters[0].Required || meta.Parameters[1].Sensitive {
+		t.Errorf("Unexpected proxied parameters %+v", meta.Parameters)
+	}
+
+	gateway, _ := inProcessClient(t, handler.MCPServer())
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "auth_login"
+	req.Params.Arguments = map[string]any{"user": "ana", "api_token": "tok-12345"}
+	result, err := gateway.CallTool(context.Background(), req)
+	if err != nil || result.Content[0].(mcp.TextContent).Text != "logged in ana with [REDACTED]" {
+		t.Errorf("Unexpected result %+v, %v", result, err)
+	}
+}
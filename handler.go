@@ -2,19 +2,66 @@ package mcpserve
 
 import (
 	"context"
-	"fmt"
+	"errors"
+	"net"
+	"net/http"
+	"regexp"
+	"sync"
+	"time"
 
+	"github.com/mark3labs/mcp-go/client"
 	"github.com/mark3labs/mcp-go/server"
 )
 
 // Config contains the configuration for Handler
 type Config struct {
 	Port          string
+	PortRange     string // Fallback ports tried when Port is taken, e.g. "3031-3040"
+	PortAuto      bool   // Fall back to an OS-assigned port when Port and PortRange are taken
 	ServerName    string // MCP server name
 	ServerVersion string // MCP server version
 	AppName       string // Application name (used to generate MCP server ID)
+
+	RateLimits       map[string]RateLimit // Per-tool rate limits keyed by tool name
+	DefaultRateLimit RateLimit            // Applied to tools without an entry in RateLimits
+	MaxQueueDepth    int                  // Max calls waiting on a serialized handler (default 16)
+	StrictTools      bool                 // Start fails on invalid tool metadata instead of logging and skipping the tool
+
+	Downstreams       []Downstream  // Third-party MCP servers re-exposed through our endpoint under a prefix
+	DownstreamTimeout time.Duration // Max time to connect to each downstream server on Start (default 10s)
+
+	ToolNamespaces         bool   // Prefix tool names with their handler's Name(), e.g. "browser_status"
+	ToolNamespaceSeparator string // Between prefix and tool name (default "_"); letters, digits, '_', '-', '.'
+
+	IDEs                  []IDEInfo // Extra IDE targets for ConfigureIDEs, on top of the built-in ones
+	IDEWorkspace          string    // Project root: ConfigureIDEs writes .vscode/mcp.json etc. there instead of the user config
+	IDEWorkspaceGitignore bool      // Add the workspace config files written by ConfigureIDEs to .gitignore
+	IDEDryRun             bool      // ConfigureIDEs only reports (with diffs) what it would change
+	IDEOptIn              []string  // IDE IDs to configure even when not detected ("vsc", "cursor", ...)
+	IDEForceCreate        bool      // Create config directories for every known IDE, installed or not
+	ConfigureIDEsOnStart  bool      // Run ConfigureIDEs from Start once the port is bound, with the real port
+	UnconfigureOnShutdown bool      // Remove the IDE entries written by ConfigureIDEs on Shutdown
+
+	MetricsPath string // Serve Prometheus metrics on this path of the MCP port, e.g. "/metrics" (off when empty)
+	Tracer      Tracer // Creates a span per tools/call, continuing incoming W3C traceparent headers (off when nil)
+
+	AuditLogPath  string   // Append a JSON line per tool call to this file (off when empty)
+	AuditMaxSize  int64    // Rotate the audit log above this size in bytes (default 10 MiB)
+	AuditMaxFiles int      // Rotated audit files kept (default 3)
+	AuditRedact   []string // Extra argument name fragments whose values are redacted, on top of password, token, secret...
+
+	OutputScrubPatterns []*regexp.Regexp // Masked in tool output on top of DefaultSecretPatterns (JWTs, API keys...)
+
+	RefreshDebounce time.Duration // Coalesce TUI refreshes after tool calls within this window (default 50ms, negative: refresh right away)
+
+	SamplingTimeout time.Duration // Max time a handler waits for a sampling response (default 60s)
+
+	ShutdownTimeout time.Duration // Grace period Shutdown waits for in-flight tool calls (default 5s)
 }
 
+// defaultShutdownTimeout is used when Config.ShutdownTimeout is not set
+const defaultShutdownTimeout = 5 * time.Second
+
 // TuiInterface defines what the MCP handler needs from the TUI
 type TuiInterface interface {
 	RefreshUI()
@@ -25,21 +72,52 @@ type Handler struct {
 	config       Config
 	toolHandlers []any // Handlers that implement GetMCPToolsMetadata (discovered via reflection)
 	tui          TuiInterface
-	exitChan     chan bool
 	log          func(messages ...any) // Private logger, set via SetLog
 
 	// Internal state
-	server any
+	server     any
+	mcpServer  *server.MCPServer // Built on first use by MCPServer
+	serverOnce sync.Once
+	limiter    *rateLimiter
+	calls      *callTracker // In-flight tool executions, drained on shutdown
+	conns      *connTracker
+	streams    *streamCloser
+	metrics    *toolMetrics
+	audit      *auditLog     // nil when Config.AuditLogPath is empty
+	ready      chan struct{} // Closed once the port is bound
+	stopped    chan struct{} // Closed once Shutdown has finished
+	mu         sync.Mutex
+	addr       net.Addr     // Bound address, set by Start
+	httpServer *http.Server // Set by Start
+	started    bool
+	stopEarly  bool // Shutdown called while Start was starting up
+	stopOnce   sync.Once
+	stopErr    error
+
+	toolsMu     sync.RWMutex            // Guards tools: downstream tools are added while calls run
+	tools       map[string]ToolMetadata // Registered tools by name, set at registration
+	downstreams []*client.Client        // Connected by Start, closed by Shutdown
+	resources   map[string]string       // Proxied resource URIs and URI templates -> downstream serving them
+	refresh     *refresher
 }
 
 // NewHandler creates a new MCP handler with minimal dependencies
-func NewHandler(config Config, toolHandlers []any, tui TuiInterface, exitChan chan bool) *Handler {
+func NewHandler(config Config, toolHandlers []any, tui TuiInterface) *Handler {
 	return &Handler{
 		config:       config,
 		toolHandlers: toolHandlers,
 		tui:          tui,
-		exitChan:     exitChan,
 		log:          func(messages ...any) {}, // No-op logger by default
+		limiter:      newRateLimiter(config.RateLimits, config.DefaultRateLimit),
+		calls:        newCallTracker(),
+		conns:        &connTracker{},
+		streams:      newStreamCloser(),
+		tools:        map[string]ToolMetadata{},
+		refresh:      newRefresher(tui, config.RefreshDebounce),
+		metrics:      newToolMetrics(),
+		audit:        newAuditLog(config),
+		ready:        make(chan struct{}),
+		stopped:      make(chan struct{}),
 	}
 }
 
@@ -55,56 +133,237 @@ func (h *Handler) SetLog(f func(message ...any)) {
 	}
 }
 
-// Serve starts the Model Context Protocol server for LLM integration via HTTP
-func (h *Handler) Serve() {
+// MCPServer returns the MCP server with the tools of all handlers registered, building it on
+// first use. Start serves it over HTTP; tests can drive it in-process (see package mcpservetest).
+func (h *Handler) MCPServer() *server.MCPServer {
+	// Not under h.mu: handlers and the logger may call back into Port, Addr...
+	h.serverOnce.Do(func() {
+		h.mcpServer = h.newMCPServer()
+	})
+	return h.mcpServer
+}
+
+// newMCPServer creates the MCP server and registers the tools of all handlers
+func (h *Handler) newMCPServer() *server.MCPServer {
 	// Create MCP server with tool capabilities
 	s := server.NewMCPServer(
 		h.config.ServerName,
 		h.config.ServerVersion,
 		server.WithToolCapabilities(true),
+		server.WithHooks(h.activityHooks()),
 	)
+	if h.wantsSampling() {
+		s.EnableSampling()
+	}
 
-	// Load tools from all registered handlers (using reflection)
-
-	// Load tools from all registered handlers (using reflection)
-	for _, handler := range h.toolHandlers {
-		if handler == nil {
-			continue
+	// Load tools from all registered handlers (using reflection), skipping invalid ones
+	problems := h.loadTools(func(handler any, tools []ToolMetadata) {
+		// The sampler and logger injected per call live on the handler, so once a handler is
+		// serialized (or samples) all its tools share one FIFO queue: an unqueued call would
+		// replace the logger capturing the output of a queued one
+		_, samples := samplerSetter(handler)
+		serialize := wantsSerialExecution(handler) || samples
+		for _, toolMeta := range tools {
+			serialize = serialize || toolMeta.Serialized
 		}
-		tools, err := h.mcpToolsFromHandler(handler)
-		if err != nil {
-			h.log(fmt.Sprintf("Warning: Failed to load tools from handler %T: %v", handler, err))
-			continue
+		var queue *handlerQueue
+		if serialize {
+			queue = newHandlerQueue(h.config.MaxQueueDepth)
 		}
+
 		for _, toolMeta := range tools {
+			h.addTool(toolMeta)
+
 			tool := buildMCPTool(toolMeta)
-			s.AddTool(*tool, h.mcpExecuteTool(handler, toolMeta.Execute))
+			s.AddTool(*tool, h.mcpExecuteTool(handler, toolMeta.Execute, queue))
 		}
+	})
+	for _, problem := range problems {
+		h.log("Warning: skipping MCP tool metadata:", problem.String())
+	}
+
+	return s
+}
+
+// Start runs the Model Context Protocol server for LLM integration via HTTP.
+// It returns an error right away when no port can be bound, or when Config.StrictTools is set
+// and a tool has invalid metadata; Ready is closed then too (with Addr nil) and Start may be
+// called again. Otherwise it blocks until ctx is cancelled (then shuts down within
+// Config.ShutdownTimeout) or Shutdown is called; a Shutdown during startup makes it return nil
+// before serving. Ready is closed as soon as the server accepts connections.
+func (h *Handler) Start(ctx context.Context) error {
+	h.mu.Lock()
+	if h.started {
+		h.mu.Unlock()
+		return errors.New("MCP server already started")
+	}
+	h.started = true
+	h.stopEarly = false
+	select {
+	case <-h.ready:
+		h.ready = make(chan struct{}) // Closed by a failed attempt
+	default:
+	}
+	h.mu.Unlock()
+
+	if h.config.StrictTools {
+		if problems := h.ValidateTools(); len(problems) > 0 {
+			return h.abortStart(&ToolValidationError{Problems: problems})
+		}
+	}
+
+	// Bind the port before anything points IDEs at it
+	listener, err := h.listen()
+	if err != nil {
+		return h.abortStart(err)
+	}
+
+	s := h.MCPServer()
+	if err := h.connectDownstreams(ctx); err != nil {
+		// The other servers still work; misconfigured ones already fail ValidateTools (and StrictTools)
+		h.log("Warning: MCP downstream servers unavailable:", err)
 	}
 
 	// Start MCP HTTP server
-	httpServer := server.NewStreamableHTTPServer(s,
+	streamableServer := server.NewStreamableHTTPServer(s,
 		server.WithEndpointPath("/mcp"),
-		server.WithStateLess(true),
+		server.WithStateLess(!h.wantsSampling()), // Sampling needs sessions to reach the client
+		server.WithHTTPContextFunc(withTraceparent),
 	)
+	mux := http.NewServeMux()
+	mux.Handle("/mcp", h.streams.wrap(streamableServer))
+	if h.config.MetricsPath != "" {
+		mux.Handle(h.config.MetricsPath, h.metricsHandler())
+	}
 
-	h.server = httpServer
+	h.mu.Lock()
+	if h.stopEarly {
+		h.mu.Unlock()
+		listener.Close()
+		h.closeDownstreams()
+		return h.abortStart(nil)
+	}
+	h.server = streamableServer
+	h.addr = listener.Addr()
+	h.httpServer = &http.Server{Handler: mux, ConnState: h.conns.track}
+	httpServer := h.httpServer
+	h.mu.Unlock()
 
-	h.log("Starting MCP HTTP server on port", h.config.Port)
-	h.log("MCP endpoint: http://localhost:" + h.config.Port + "/mcp")
+	port := h.Port()
+	h.log("Starting MCP HTTP server on port", port)
+	h.log("MCP endpoint: " + mcpServerURL(port))
 
+	serveErr := make(chan error, 1)
 	go func() {
-		if err := httpServer.Start(":" + h.config.Port); err != nil {
+		serveErr <- httpServer.Serve(listener)
+	}()
+	close(h.readyChan())
+
+	if h.config.ConfigureIDEsOnStart {
+		h.ConfigureIDEs()
+	}
+
+	select {
+	case <-ctx.Done():
+		return h.Shutdown(context.Background())
+	case err := <-serveErr:
+		if err != http.ErrServerClosed {
 			h.log("MCP HTTP server stopped:", err)
+			h.Shutdown(context.Background())
+			return err
+		}
+		// Shutdown was called: wait for it to finish draining
+		<-h.stopped
+		return nil
+	}
+}
+
+// Ready returns a channel closed once Start has bound the port and accepts connections,
+// or once Start has failed before serving (Addr is nil then). A new Start call replaces it.
+func (h *Handler) Ready() <-chan struct{} {
+	return h.readyChan()
+}
+
+func (h *Handler) readyChan() chan struct{} {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.ready
+}
+
+// abortStart wakes Ready waiters after Start failed before serving, and allows Start again
+func (h *Handler) abortStart(err error) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.started = false
+	close(h.ready)
+	return err
+}
+
+// Shutdown stops the server gracefully: new tool calls are refused with "server shutting down"
+// and in-flight ones are waited for until ctx is done. Without a ctx deadline, Config.ShutdownTimeout
+// (default 5s) applies, so Shutdown always returns in bounded time. Calls still running at the
+// deadline are reported in a *ShutdownError. Safe to call more than once and before Start;
+// while Start is still starting up, it makes Start return nil without serving.
+func (h *Handler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	httpServer := h.httpServer
+	if httpServer == nil && h.started {
+		h.stopEarly = true // Start checks it before serving
+	}
+	h.mu.Unlock()
+	if httpServer == nil {
+		return nil // Not serving yet
+	}
+
+	h.stopOnce.Do(func() {
+		defer close(h.stopped)
+
+		if _, ok := ctx.Deadline(); !ok {
+			var cancel context.CancelFunc
+			ctx, cancel = context.WithTimeout(ctx, h.shutdownTimeout())
+			defer cancel()
 		}
-	}()
 
-	_, ok := <-h.exitChan
-	if !ok {
 		h.log("Shutting down MCP server...")
-		ctx := context.Background()
-		if err := httpServer.Shutdown(ctx); err != nil {
+		h.calls.drain() // Refuse new tool calls from now on
+		if h.config.UnconfigureOnShutdown {
+			if err := h.UnconfigureIDEs(); err != nil {
+				h.log("Error removing IDE configuration:", err)
+			}
+		}
+
+		// Stop accepting connections and wait for open requests, then for executions
+		// that outlive their request (the client may have disconnected)
+		h.conns.closeUnused()
+		h.streams.close()
+		err := httpServer.Shutdown(ctx)
+		if waitErr := h.calls.wait(ctx); waitErr != nil {
+			interrupted := h.calls.tools()
+			for _, tool := range interrupted {
+				h.log("Tool call interrupted by shutdown:", tool)
+			}
+			err = &ShutdownError{Interrupted: interrupted, Err: waitErr}
+		}
+		if err != nil {
 			h.log("Error shutting down MCP server:", err)
+			httpServer.Close()
+		}
+		if h.audit != nil {
+			h.audit.close()
 		}
+		h.refresh.flush()
+		h.closeDownstreams()
+		h.stopErr = err
+	})
+
+	<-h.stopped
+	return h.stopErr
+}
+
+// shutdownTimeout returns Config.ShutdownTimeout or its default
+func (h *Handler) shutdownTimeout() time.Duration {
+	if h.config.ShutdownTimeout > 0 {
+		return h.config.ShutdownTimeout
 	}
+	return defaultShutdownTimeout
 }
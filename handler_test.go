@@ -1,8 +1,14 @@
 package mcpserve
 
 import (
+	"context"
+	"errors"
+	"net"
+	"strconv"
 	"testing"
 	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
 )
 
 // mockHandler implements GetMCPToolsMetadata for testing
@@ -43,10 +49,9 @@ func TestNewHandler(t *testing.T) {
 	}
 
 	mockHandlers := []any{&mockHandler{}}
-	exitChan := make(chan bool, 1)
 	tui := &mockTUI{}
 
-	handler := NewHandler(config, mockHandlers, tui, exitChan)
+	handler := NewHandler(config, mockHandlers, tui)
 
 	if handler == nil {
 		t.Fatal("NewHandler returned nil")
@@ -67,8 +72,7 @@ func TestToolDiscovery(t *testing.T) {
 
 	// Create a handler to use the method
 	config := Config{Port: "3030", ServerName: "Test", ServerVersion: "1.0.0"}
-	exitChan := make(chan bool, 1)
-	handler := NewHandler(config, []any{mock}, &mockTUI{}, exitChan)
+	handler := NewHandler(config, []any{mock}, &mockTUI{})
 
 	tools, err := handler.mcpToolsFromHandler(mock)
 
@@ -96,8 +100,7 @@ func TestToolExecution(t *testing.T) {
 
 	// Create a handler to use the method
 	config := Config{Port: "3030", ServerName: "Test", ServerVersion: "1.0.0"}
-	exitChan := make(chan bool, 1)
-	handler := NewHandler(config, []any{mock}, &mockTUI{}, exitChan)
+	handler := NewHandler(config, []any{mock}, &mockTUI{})
 
 	tools, err := handler.mcpToolsFromHandler(mock)
 	if err != nil {
@@ -122,40 +125,269 @@ func TestToolExecution(t *testing.T) {
 	}
 }
 
-// TestServeStartsServer verifies Serve starts without error
-func TestServeStartsServer(t *testing.T) {
+// startTestHandler runs Start in the background and waits for Ready
+func startTestHandler(t *testing.T, handler *Handler) <-chan error {
+	t.Helper()
+	done := make(chan error, 1)
+	go func() {
+		done <- handler.Start(context.Background())
+	}()
+
+	select {
+	case <-handler.Ready():
+		if handler.Addr() == nil {
+			t.Fatalf("Start failed: %v", <-done)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Server did not become ready")
+	}
+	return done
+}
+
+// TestStartShutdown verifies Start, Ready and Shutdown
+func TestStartShutdown(t *testing.T) {
 	config := Config{
-		Port:          "3031", // Use different port to avoid conflicts
+		Port:          "0",
 		ServerName:    "Test Server",
 		ServerVersion: "1.0.0",
 	}
 
-	mock := &mockHandler{}
-	exitChan := make(chan bool, 1)
-	tui := &mockTUI{}
-
-	handler := NewHandler(config, []any{mock}, tui, exitChan)
+	handler := NewHandler(config, []any{&mockHandler{}}, &mockTUI{})
 	handler.SetLog(func(messages ...any) { t.Log(messages...) })
+	done := startTestHandler(t, handler)
+
+	// A connection that never sends a request must not hold up shutdown
+	conn, err := net.Dial("tcp", "localhost:"+handler.Port())
+	if err != nil {
+		t.Fatalf("Server not reachable after Ready: %v", err)
+	}
+	defer conn.Close()
+	time.Sleep(20 * time.Millisecond) // Let the server accept it
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := handler.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start returned %v after Shutdown", err)
+	}
+	if err := handler.Shutdown(context.Background()); err != nil {
+		t.Errorf("Second Shutdown: %v", err)
+	}
+	if err := handler.Start(context.Background()); err == nil {
+		t.Error("Expected an error when starting twice")
+	}
+}
+
+// TestStartContextCancel verifies cancelling the Start context shuts the server down
+func TestStartContextCancel(t *testing.T) {
+	handler := NewHandler(Config{Port: "0"}, nil, &mockTUI{})
+	ctx, cancel := context.WithCancel(context.Background())
 
-	// Start server in goroutine
-	serverStarted := make(chan bool, 1)
+	done := make(chan error, 1)
 	go func() {
-		serverStarted <- true
-		handler.Serve()
+		done <- handler.Start(ctx)
 	}()
+	<-handler.Ready()
+	cancel()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start returned %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start did not return after cancel")
+	}
+}
+
+// slowHandler takes a while to list its tools, keeping Start in its startup phase
+type slowHandler struct{}
+
+func (slowHandler) GetMCPToolsMetadata() []ToolMetadata {
+	time.Sleep(100 * time.Millisecond)
+	return []ToolMetadata{{Name: "slow", Description: "Slow to load", Execute: func(map[string]any) {}}}
+}
+
+// TestShutdownDuringStart verifies Shutdown called while Start is starting up stops it before serving
+func TestShutdownDuringStart(t *testing.T) {
+	free, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(free.Addr().(*net.TCPAddr).Port)
+	free.Close()
+
+	handler := NewHandler(Config{Port: port}, []any{slowHandler{}}, &mockTUI{})
+	done := make(chan error, 1)
+	go func() { done <- handler.Start(context.Background()) }()
+
+	time.Sleep(20 * time.Millisecond)
+	if err := handler.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown returned %v", err)
+	}
+
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Start returned %v", err)
+		}
+	case <-time.After(2 * time.Second):
+		t.Fatal("Start kept serving after Shutdown")
+	}
+	if handler.Addr() != nil {
+		t.Error("No address should be reported when Start stopped before serving")
+	}
+	select {
+	case <-handler.Ready():
+	default:
+		t.Error("Ready should be closed when Start stopped before serving")
+	}
+
+	// The port is released
+	listener, err := net.Listen("tcp", ":"+port)
+	if err != nil {
+		t.Fatalf("Port still bound: %v", err)
+	}
+	listener.Close()
+}
 
-	// Wait for goroutine to start
-	<-serverStarted
+// TestShutdownDrainsCalls verifies Shutdown waits for in-flight calls up to its deadline
+func TestShutdownDrainsCalls(t *testing.T) {
+	handler := NewHandler(Config{Port: "0"}, nil, &mockTUI{})
+	startTestHandler(t, handler)
+
+	end, _ := handler.calls.begin("build")
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err := handler.Shutdown(ctx)
+	var shutdownErr *ShutdownError
+	if !errors.As(err, &shutdownErr) || len(shutdownErr.Interrupted) != 1 || shutdownErr.Interrupted[0] != "build" {
+		t.Errorf("Expected build to be reported as interrupted, got %v", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Expected DeadlineExceeded, got %v", err)
+	}
+	end()
+
+	// New calls are refused once draining
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "test_tool"
+	result, _ := handler.mcpExecuteTool(&mockHandler{}, func(map[string]any) {}, nil)(context.Background(), req)
+	if !result.IsError || result.Content[0].(mcp.TextContent).Text != "server shutting down" {
+		t.Errorf("Expected a shutting down error, got %+v", result)
+	}
 
-	// Give server time to start
-	time.Sleep(200 * time.Millisecond)
+	// A call that finishes within the deadline is waited for
+	handler = NewHandler(Config{Port: "0"}, nil, &mockTUI{})
+	startTestHandler(t, handler)
+	end, _ = handler.calls.begin("build")
+	time.AfterFunc(20*time.Millisecond, end)
+	if err := handler.Shutdown(context.Background()); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if n := handler.calls.count(); n != 0 {
+		t.Errorf("Shutdown returned with %d calls running", n)
+	}
+}
 
-	// Signal exit
-	close(exitChan)
+// TestStartPortFallback verifies a busy port is detected and an OS-assigned port is used instead
+func TestStartPortFallback(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := strconv.Itoa(busy.Addr().(*net.TCPAddr).Port)
 
-	// Give server time to shutdown
-	time.Sleep(200 * time.Millisecond)
+	handler := NewHandler(Config{Port: busyPort, PortAuto: true}, []any{&mockHandler{}}, &mockTUI{})
+	startTestHandler(t, handler)
+	defer handler.Shutdown(context.Background())
 
-	// If we reach here without panic, test passes
-	t.Log("✓ Server started and stopped successfully")
+	if handler.Port() == busyPort {
+		t.Errorf("Expected a port other than the busy %s", busyPort)
+	}
+
+	conn, err := net.Dial("tcp", "localhost:"+handler.Port())
+	if err != nil {
+		t.Errorf("Bound port not reachable: %v", err)
+	} else {
+		conn.Close()
+	}
+}
+
+// TestStartPortConflict verifies Start returns an error when the port is taken and no fallback is set
+func TestStartPortConflict(t *testing.T) {
+	busy, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer busy.Close()
+	busyPort := strconv.Itoa(busy.Addr().(*net.TCPAddr).Port)
+
+	handler := NewHandler(Config{Port: busyPort}, nil, &mockTUI{})
+	if err := handler.Start(context.Background()); err == nil {
+		t.Fatal("Expected an error for a busy port")
+	}
+	if handler.Addr() != nil {
+		t.Error("No address should be reported when binding failed")
+	}
+	select {
+	case <-handler.Ready():
+	default:
+		t.Error("Ready should be closed when binding failed, so waiters don't hang")
+	}
+
+	// Start can be retried once the port is free
+	busy.Close()
+	done := make(chan error, 1)
+	go func() { done <- handler.Start(context.Background()) }()
+	for deadline := time.Now().Add(2 * time.Second); handler.Addr() == nil; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatal("Retried Start did not bind the port")
+		}
+	}
+	if handler.Port() != busyPort {
+		t.Errorf("Expected retry to bind %s, got %s", busyPort, handler.Port())
+	}
+	handler.Shutdown(context.Background())
+	if err := <-done; err != nil {
+		t.Errorf("Retried Start returned %v", err)
+	}
+}
+
+// portAwareHandler reads the MCP port while its tools are loaded
+type portAwareHandler struct {
+	mcp *Handler
+}
+
+func (p *portAwareHandler) GetMCPToolsMetadata() []ToolMetadata {
+	return []ToolMetadata{
+		{Name: "serve", Description: "Serves on port " + p.mcp.Port(), Execute: func(map[string]any) {}},
+		{Name: "", Execute: func(map[string]any) {}}, // Logged as invalid
+	}
+}
+
+// TestMCPServerCallbacks verifies handlers and the logger may call back into the Handler while the server is built
+func TestMCPServerCallbacks(t *testing.T) {
+	handler := &portAwareHandler{}
+	handler.mcp = NewHandler(Config{Port: "3030"}, []any{handler}, &mockTUI{})
+	handler.mcp.SetLog(func(messages ...any) {
+		t.Log(append(messages, "addr:", handler.mcp.Addr())...)
+	})
+
+	built := make(chan struct{})
+	go func() {
+		handler.mcp.MCPServer()
+		close(built)
+	}()
+	select {
+	case <-built:
+	case <-time.After(2 * time.Second):
+		t.Fatal("MCPServer deadlocked")
+	}
+	if tool := handler.mcp.MCPServer().GetTool("serve"); tool == nil || tool.Tool.Description != "Serves on port 3030" {
+		t.Errorf("Unexpected tool %+v", tool)
+	}
 }
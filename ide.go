@@ -3,8 +3,10 @@ package mcpserve
 import (
 	"errors"
 	"os"
+	"os/exec"
 	"path/filepath"
 	"runtime"
+	"slices"
 )
 
 // IDEInfo represents a supported IDE and its configuration path resolver
@@ -13,92 +15,318 @@ type IDEInfo struct {
 	Name           string
 	GetConfigDir   func() (string, error)
 	ConfigFileName string
+	ServersKey     string                     // Top-level object holding MCP servers ("servers", "mcpServers", "context_servers")
+	Entry          func(serverURL string) any // Builds the server entry in this IDE's schema
+	NewFile        string                     // Content for a config file that doesn't exist yet (default "{}")
+	Binaries       []string                   // Executables whose presence on PATH means the IDE is installed
+	WorkspaceFile  string                     // Project-level config path relative to the project root (empty = unsupported)
 }
 
 // ConfigureIDEs automatically configures supported IDEs with this MCP server
-func (h *Handler) ConfigureIDEs() {
+// Built-in targets are extended with Config.IDEs
+// Writes the user's global config, or the project's config when Config.IDEWorkspace is set
+// Returns a per-IDE, per-profile report, also summarized through the handler logger.
+// With Config.IDEDryRun nothing is written and the report carries the diffs instead.
+func (h *Handler) ConfigureIDEs() IDEReport {
+	ides := append(defaultIDEs(), h.config.IDEs...)
+
+	var report IDEReport
+	if h.config.IDEWorkspace != "" {
+		report = h.configureWorkspaceIDEs(ides)
+	} else {
+		report = h.configureUserIDEs(ides)
+	}
+	report.DryRun = h.config.IDEDryRun
+
+	h.logIDEReport(report)
+	return report
+}
+
+// configureUserIDEs writes the user-level config of every installed IDE and all its profiles
+func (h *Handler) configureUserIDEs(ides []IDEInfo) IDEReport {
+	var report IDEReport
+
+	for _, ide := range ides {
+		skip := func(path string, found bool, reason string, err error) {
+			status := IDESkipped
+			if err != nil {
+				status = IDEFailed
+			}
+			report.Results = append(report.Results, IDEResult{
+				IDE: ide.ID, Name: ide.Name, Profile: "default", Path: path,
+				Found: found, Status: status, Reason: reason, Err: err,
+			})
+		}
+
+		basePath, err := ide.GetConfigDir()
+		if err != nil {
+			skip("", false, err.Error(), nil)
+			continue
+		}
+
+		// Create the directory if it doesn't exist, but only for IDEs that are actually present
+		if _, err := os.Stat(basePath); os.IsNotExist(err) {
+			if !h.ideInstalled(ide, basePath) {
+				skip(basePath, false, "not installed", nil)
+				continue
+			}
+			if !h.config.IDEDryRun {
+				if err := os.MkdirAll(basePath, 0755); err != nil {
+					if reason, ok := permissionReason(err); ok {
+						skip(basePath, false, reason, nil)
+					} else {
+						skip(basePath, false, "", err)
+					}
+					continue
+				}
+			}
+		}
+
+		configPaths, err := findMCPConfigPaths(basePath, ide.ConfigFileName)
+		if err != nil {
+			if h.config.IDEDryRun && os.IsNotExist(err) {
+				// Directory would be created
+				configPaths = []string{filepath.Join(basePath, ide.ConfigFileName)}
+			} else {
+				skip(basePath, true, "", err)
+				continue
+			}
+		}
+
+		for _, configPath := range configPaths {
+			report.Results = append(report.Results, h.configureIDEFile(configPath, ide, profileName(basePath, configPath)))
+		}
+	}
+
+	return report
+}
+
+// ideInstalled reports whether an IDE is present on this machine: its config directory exists,
+// one of its binaries is on PATH, or it is listed in Config.IDEOptIn / forced by Config.IDEForceCreate.
+func (h *Handler) ideInstalled(ide IDEInfo, basePath string) bool {
+	if h.config.IDEForceCreate || slices.Contains(h.config.IDEOptIn, ide.ID) {
+		return true
+	}
+	if basePath != "" && dirExists(basePath) {
+		return true
+	}
+	for _, binary := range ide.Binaries {
+		if _, err := exec.LookPath(binary); err == nil {
+			return true
+		}
+	}
+	return false
+}
+
+// defaultIDEs returns the built-in IDE targets
+func defaultIDEs() []IDEInfo {
 	ides := []IDEInfo{
 		{
 			ID:             "vsc",
 			Name:           "Visual Studio Code",
 			GetConfigDir:   getVSCodeConfigPath,
 			ConfigFileName: "mcp.json",
+			ServersKey:     "servers",
+			Entry:          vscodeEntry,
+			NewFile:        newVSCodeMCPConfig,
+			WorkspaceFile:  filepath.Join(".vscode", "mcp.json"),
+			Binaries:       []string{"code"},
 		},
+	}
+	ides = append(ides, vscodeVariants()...)
+
+	return append(ides, []IDEInfo{
 		{
 			ID:   "antigravity",
 			Name: "Antigravity",
 			GetConfigDir: func() (string, error) {
-				homeDir, err := os.UserHomeDir()
-				if err != nil {
-					return "", err
-				}
 				// The correct path for Antigravity config is ~/.gemini/antigravity
-				return filepath.Join(homeDir, ".gemini", "antigravity"), nil
+				return homePath(".gemini", "antigravity")
 			},
 			ConfigFileName: "mcp_config.json",
+			ServersKey:     "servers",
+			Entry:          vscodeEntry,
+			NewFile:        newVSCodeMCPConfig,
+			Binaries:       []string{"antigravity"},
+		},
+		{
+			ID:   "cursor",
+			Name: "Cursor",
+			GetConfigDir: func() (string, error) {
+				return homePath(".cursor")
+			},
+			ConfigFileName: "mcp.json",
+			ServersKey:     "mcpServers",
+			Entry: func(serverURL string) any {
+				return mcpServerConfig{URL: serverURL}
+			},
+			WorkspaceFile: filepath.Join(".cursor", "mcp.json"),
+			Binaries:      []string{"cursor"},
 		},
+		{
+			ID:   "claude-desktop",
+			Name: "Claude Desktop",
+			GetConfigDir: func() (string, error) {
+				return userConfigPath("Claude")
+			},
+			ConfigFileName: "claude_desktop_config.json",
+			ServersKey:     "mcpServers",
+			// Claude Desktop only launches stdio servers, bridge through mcp-remote
+			Entry: func(serverURL string) any {
+				return mcpServerConfig{Command: "npx", Args: []string{"-y", "mcp-remote", serverURL}}
+			},
+		},
+		{
+			ID:   "windsurf",
+			Name: "Windsurf",
+			GetConfigDir: func() (string, error) {
+				return homePath(".codeium", "windsurf")
+			},
+			ConfigFileName: "mcp_config.json",
+			ServersKey:     "mcpServers",
+			Entry: func(serverURL string) any {
+				return mcpServerConfig{ServerURL: serverURL}
+			},
+			Binaries: []string{"windsurf"},
+		},
+		{
+			ID:             "zed",
+			Name:           "Zed",
+			GetConfigDir:   getZedConfigPath,
+			ConfigFileName: "settings.json",
+			ServersKey:     "context_servers",
+			Entry: func(serverURL string) any {
+				return mcpServerConfig{URL: serverURL}
+			},
+			WorkspaceFile: filepath.Join(".zed", "settings.json"),
+			Binaries:      []string{"zed", "zeditor"},
+		},
+	}...)
+}
+
+// vscodeEntry builds the streamable HTTP entry used by VS Code's mcp.json
+func vscodeEntry(serverURL string) any {
+	return mcpServerConfig{URL: serverURL, Type: "http"}
+}
+
+// homePath joins parts onto the user's home directory
+func homePath(parts ...string) (string, error) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
 	}
+	return filepath.Join(append([]string{homeDir}, parts...)...), nil
+}
 
-	for _, ide := range ides {
-		basePath, err := ide.GetConfigDir()
-		if err != nil {
-			continue
-		}
+// userConfigPath joins parts onto the platform config directory
+// (~/.config, ~/Library/Application Support or %APPDATA%)
+func userConfigPath(parts ...string) (string, error) {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(append([]string{configDir}, parts...)...), nil
+}
 
-		// Create the directory if it doesn't exist
-		if _, err := os.Stat(basePath); os.IsNotExist(err) {
-			if err := os.MkdirAll(basePath, 0755); err != nil {
-				continue // Silent failure
-			}
-		}
+// getZedConfigPath returns the Zed settings directory, which uses ~/.config on macOS too
+func getZedConfigPath() (string, error) {
+	if runtime.GOOS == "windows" {
+		return userConfigPath("Zed")
+	}
+	return homePath(".config", "zed")
+}
 
-		configPaths, err := findMCPConfigPaths(basePath, ide.ConfigFileName)
+// getVSCodeConfigPath returns the platform-specific VS Code User directory path.
+func getVSCodeConfigPath() (string, error) {
+	return vscodeUserDir("Code")()
+}
+
+// vscodeUserDir returns a resolver for the User directory of a desktop VS Code build
+// product is the folder name of the build: "Code", "Code - Insiders", "VSCodium", "Code - OSS"
+func vscodeUserDir(product string) func() (string, error) {
+	return func() (string, error) {
+		homeDir, err := os.UserHomeDir()
 		if err != nil {
-			continue
+			return "", err
 		}
 
-		for _, configPath := range configPaths {
-			_ = updateMCPConfig(configPath, h.config.AppName, h.config.Port)
+		switch runtime.GOOS {
+		case "linux":
+			return filepath.Join(homeDir, ".config", product, "User"), nil
+		case "darwin":
+			return filepath.Join(homeDir, "Library", "Application Support", product, "User"), nil
+		case "windows":
+			appData := os.Getenv("APPDATA")
+			if appData == "" {
+				return "", errors.New("APPDATA environment variable not set")
+			}
+			return filepath.Join(appData, product, "User"), nil
+		default:
+			return "", errors.New("unsupported platform: " + runtime.GOOS)
 		}
 	}
 }
 
-// getVSCodeConfigPath returns the platform-specific VS Code User directory path.
-func getVSCodeConfigPath() (string, error) {
-	homeDir, err := os.UserHomeDir()
-	if err != nil {
-		return "", err
+// vscodeServerUserDir returns a resolver for the User directory of a VS Code Server install
+// serverDir is the folder in the home directory: ".vscode-server", ".vscode-server-insiders", ...
+// These exist on machines reached through Remote SSH, WSL and dev containers.
+func vscodeServerUserDir(serverDir string) func() (string, error) {
+	return func() (string, error) {
+		return homePath(serverDir, "data", "User")
+	}
+}
+
+// vscodeVariants returns the VS Code builds besides stable, which are only configured when present
+func vscodeVariants() []IDEInfo {
+	variants := []struct {
+		id, name     string
+		getConfigDir func() (string, error)
+		binary       string // Empty for servers, which are only detected by their directory
+	}{
+		{"vsc-insiders", "Visual Studio Code - Insiders", vscodeUserDir("Code - Insiders"), "code-insiders"},
+		{"vscodium", "VSCodium", vscodeUserDir("VSCodium"), "codium"},
+		{"code-oss", "Code - OSS", vscodeUserDir("Code - OSS"), "code-oss"},
+		{"vscode-server", "VS Code Server (Remote SSH, WSL, dev containers)", vscodeServerUserDir(".vscode-server"), ""},
+		{"vscode-server-insiders", "VS Code Server - Insiders", vscodeServerUserDir(".vscode-server-insiders"), ""},
+		{"vscodium-server", "VSCodium Server", vscodeServerUserDir(".vscodium-server"), ""},
+		{"vscode-remote", "VS Code Remote (dev containers)", vscodeServerUserDir(".vscode-remote"), ""},
 	}
 
-	switch runtime.GOOS {
-	case "linux":
-		return filepath.Join(homeDir, ".config", "Code", "User"), nil
-	case "darwin":
-		return filepath.Join(homeDir, "Library", "Application Support", "Code", "User"), nil
-	case "windows":
-		appData := os.Getenv("APPDATA")
-		if appData == "" {
-			return "", errors.New("APPDATA environment variable not set")
+	ides := make([]IDEInfo, len(variants))
+	for i, v := range variants {
+		ides[i] = IDEInfo{
+			ID:             v.id,
+			Name:           v.name,
+			GetConfigDir:   v.getConfigDir,
+			ConfigFileName: "mcp.json",
+			ServersKey:     "servers",
+			Entry:          vscodeEntry,
+			NewFile:        newVSCodeMCPConfig,
+			WorkspaceFile:  filepath.Join(".vscode", "mcp.json"),
+		}
+		if v.binary != "" {
+			ides[i].Binaries = []string{v.binary}
 		}
-		return filepath.Join(appData, "Code", "User"), nil
-	default:
-		return "", errors.New("unsupported platform: " + runtime.GOOS)
 	}
+	return ides
 }
 
 // findMCPConfigPaths resolves all mcp.json (or specified) file paths based on IDE profile structure.
+// The default profile lives in basePath; named profiles live in basePath/profiles/<id>.
 func findMCPConfigPaths(basePath string, configFileName string) ([]string, error) {
 	// Check if the base directory exists
-	if _, err := os.Stat(basePath); os.IsNotExist(err) {
-		return nil, errors.New("directory not found")
+	if _, err := os.Stat(basePath); err != nil {
+		return nil, err
 	}
 
+	configPaths := []string{filepath.Join(basePath, configFileName)}
+
 	profilesPath := filepath.Join(basePath, "profiles")
 
 	// Check if profiles directory exists
 	if _, err := os.Stat(profilesPath); os.IsNotExist(err) {
 		// No profiles, use base path
-		return []string{filepath.Join(basePath, configFileName)}, nil
+		return configPaths, nil
 	}
 
 	// Get all profile directories
@@ -107,16 +335,11 @@ func findMCPConfigPaths(basePath string, configFileName string) ([]string, error
 		return nil, err
 	}
 
-	configPaths := []string{}
 	for _, entry := range entries {
 		if entry.IsDir() {
 			configPaths = append(configPaths, filepath.Join(profilesPath, entry.Name(), configFileName))
 		}
 	}
 
-	if len(configPaths) == 0 {
-		return []string{filepath.Join(basePath, configFileName)}, nil
-	}
-
 	return configPaths, nil
 }
@@ -0,0 +1,279 @@
+package mcpserve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// setTestHome points the home and config directories at a temp dir
+func setTestHome(t *testing.T) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("IDE paths are resolved from APPDATA on Windows")
+	}
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(home, ".config"))
+	t.Setenv("PATH", "") // No IDE binaries: detection relies on directories only
+	return home
+}
+
+// TestConfigureIDEsSchemas verifies each installed IDE gets an entry in its own schema
+func TestConfigureIDEsSchemas(t *testing.T) {
+	home := setTestHome(t)
+	os.MkdirAll(filepath.Join(home, ".cursor"), 0755)
+	os.MkdirAll(filepath.Join(home, ".codeium", "windsurf"), 0755)
+
+	handler := NewHandler(Config{Port: "3030", AppName: "TinyWasm"}, nil, nil)
+	handler.ConfigureIDEs()
+
+	cursor := readTestFile(t, filepath.Join(home, ".cursor", "mcp.json"))
+	if !strings.Contains(cursor, `"mcpServers"`) || !strings.Contains(cursor, `"url": "http://localhost:3030/mcp"`) {
+		t.Errorf("Unexpected Cursor config:\n%s", cursor)
+	}
+
+	windsurf := readTestFile(t, filepath.Join(home, ".codeium", "windsurf", "mcp_config.json"))
+	if !strings.Contains(windsurf, `"serverUrl": "http://localhost:3030/mcp"`) {
+		t.Errorf("Unexpected Windsurf config:\n%s", windsurf)
+	}
+
+	// IDEs that aren't installed are left alone
+	if _, err := os.Stat(filepath.Join(home, ".config", "zed")); !os.IsNotExist(err) {
+		t.Error("Zed config directory should not be created")
+	}
+}
+
+// TestConfigureIDEsVSCodeVariants verifies Insiders, VS Code Server and profile folders are configured
+func TestConfigureIDEsVSCodeVariants(t *testing.T) {
+	home := setTestHome(t)
+	insiders, _ := vscodeUserDir("Code - Insiders")()
+	os.MkdirAll(filepath.Join(insiders, "profiles", "-5f2a1b"), 0755)
+	server := filepath.Join(home, ".vscode-server", "data", "User")
+	os.MkdirAll(server, 0755)
+
+	var logged []string
+	handler := NewHandler(Config{Port: "3030", AppName: "TinyWasm"}, nil, nil)
+	handler.SetLog(func(messages ...any) {
+		logged = append(logged, fmt.Sprint(messages...))
+	})
+	handler.ConfigureIDEs()
+
+	for _, path := range []string{
+		filepath.Join(insiders, "mcp.json"),
+		filepath.Join(insiders, "profiles", "-5f2a1b", "mcp.json"),
+		filepath.Join(server, "mcp.json"),
+	} {
+		if data := readTestFile(t, path); !strings.Contains(data, `"tinywasm-mcp"`) {
+			t.Errorf("%s not configured:\n%s", path, data)
+		}
+	}
+
+	if !strings.Contains(strings.Join(logged, "\n"), "VS Code Server") {
+		t.Errorf("Found IDEs were not reported: %v", logged)
+	}
+}
+
+// TestConfigureIDEsWorkspace verifies project-level files and .gitignore entries
+func TestConfigureIDEsWorkspace(t *testing.T) {
+	home := setTestHome(t)
+	project := t.TempDir()
+	os.MkdirAll(filepath.Join(project, ".vscode"), 0755)
+	writeTestFile(t, filepath.Join(project, ".gitignore"), "node_modules")
+
+	handler := NewHandler(Config{
+		Port:                  "3030",
+		AppName:               "TinyWasm",
+		IDEWorkspace:          project,
+		IDEWorkspaceGitignore: true,
+	}, nil, nil)
+	handler.ConfigureIDEs()
+	handler.ConfigureIDEs() // Idempotent
+
+	if data := readTestFile(t, filepath.Join(project, ".vscode", "mcp.json")); !strings.Contains(data, `"tinywasm-mcp"`) {
+		t.Errorf("Workspace config not written:\n%s", data)
+	}
+	if got, want := readTestFile(t, filepath.Join(project, ".gitignore")), "node_modules\n/.vscode/mcp.json\n/.vscode/mcp.json.bak-*\n"; got != want {
+		t.Errorf("Unexpected .gitignore:\n%q\nwant:\n%q", got, want)
+	}
+
+	// Global config and folders of other IDEs are untouched
+	if _, err := os.Stat(filepath.Join(home, ".config", "Code")); !os.IsNotExist(err) {
+		t.Error("User-level VS Code config should not be written in workspace mode")
+	}
+	if _, err := os.Stat(filepath.Join(project, ".cursor")); !os.IsNotExist(err) {
+		t.Error(".cursor should not be created when Cursor is not used")
+	}
+}
+
+// TestUnconfigureIDEs verifies only entries written by ConfigureIDEs are removed
+func TestUnconfigureIDEs(t *testing.T) {
+	home := setTestHome(t)
+	cursorConfig := filepath.Join(home, ".cursor", "mcp.json")
+	windsurfConfig := filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")
+	os.MkdirAll(filepath.Dir(cursorConfig), 0755)
+	os.MkdirAll(filepath.Dir(windsurfConfig), 0755)
+	writeTestFile(t, cursorConfig, `{"mcpServers": {"git": {"command": "uvx"}}}`)
+
+	handler := NewHandler(Config{Port: "3030", AppName: "TinyWasm"}, nil, nil)
+	handler.ConfigureIDEs()
+
+	// The user repoints the Windsurf entry by hand: it is no longer ours to remove
+	edited := `{"mcpServers": {"tinywasm-mcp": {"serverUrl": "http://localhost:9999/mcp"}}}`
+	writeTestFile(t, windsurfConfig, edited)
+
+	if err := handler.UnconfigureIDEs(); err != nil {
+		t.Fatalf("UnconfigureIDEs failed: %v", err)
+	}
+
+	cursor := readTestFile(t, cursorConfig)
+	if strings.Contains(cursor, "tinywasm-mcp") || !strings.Contains(cursor, `"git"`) {
+		t.Errorf("Unexpected Cursor config after cleanup:\n%s", cursor)
+	}
+	if got := readTestFile(t, windsurfConfig); got != edited {
+		t.Errorf("User-edited entry was modified:\n%s", got)
+	}
+
+	manifest, _ := manifestPath("tinywasm-mcp")
+	if _, err := os.Stat(manifest); !os.IsNotExist(err) {
+		t.Error("Manifest should be deleted once every entry is removed")
+	}
+}
+
+// TestUnconfigureIDEsOwnership verifies hand-written entries and those of other instances survive
+func TestUnconfigureIDEsOwnership(t *testing.T) {
+	home := setTestHome(t)
+	cursorConfig := filepath.Join(home, ".cursor", "mcp.json")
+	windsurfConfig := filepath.Join(home, ".codeium", "windsurf", "mcp_config.json")
+	os.MkdirAll(filepath.Dir(windsurfConfig), 0755)
+
+	// The user already has exactly our entry in Cursor: ConfigureIDEs leaves it, so it isn't ours
+	handWritten := `{"mcpServers": {"tinywasm-mcp": {"url": "http://localhost:3030/mcp"}}}`
+	os.MkdirAll(filepath.Dir(cursorConfig), 0755)
+	writeTestFile(t, cursorConfig, handWritten)
+	a := NewHandler(Config{Port: "3030", AppName: "TinyWasm"}, nil, nil)
+	a.ConfigureIDEs()
+	if err := a.UnconfigureIDEs(); err != nil {
+		t.Fatalf("UnconfigureIDEs failed: %v", err)
+	}
+	if got := readTestFile(t, cursorConfig); got != handWritten {
+		t.Errorf("Hand-written entry was changed:\n%s", got)
+	}
+
+	// Two checkouts on different ports, each with its own workspace
+	projectA, projectB := t.TempDir(), t.TempDir()
+	os.MkdirAll(filepath.Join(projectA, ".vscode"), 0755)
+	os.MkdirAll(filepath.Join(projectB, ".vscode"), 0755)
+	b := NewHandler(Config{Port: "3031", AppName: "TinyWasm"}, nil, nil)
+	workspaceA := NewHandler(Config{Port: "3030", AppName: "TinyWasm", IDEWorkspace: projectA}, nil, nil)
+	workspaceB := NewHandler(Config{Port: "3031", AppName: "TinyWasm", IDEWorkspace: projectB}, nil, nil)
+	a.ConfigureIDEs()
+	workspaceA.ConfigureIDEs()
+	b.ConfigureIDEs() // Repoints the user-level entries at B
+	workspaceB.ConfigureIDEs()
+
+	if err := a.UnconfigureIDEs(); err != nil {
+		t.Fatalf("UnconfigureIDEs failed: %v", err)
+	}
+	if got := readTestFile(t, filepath.Join(projectA, ".vscode", "mcp.json")); strings.Contains(got, "tinywasm-mcp") {
+		t.Errorf("A's workspace entry was not removed:\n%s", got)
+	}
+	if got := readTestFile(t, filepath.Join(projectB, ".vscode", "mcp.json")); !strings.Contains(got, "3031") {
+		t.Errorf("B's workspace entry was removed by A:\n%s", got)
+	}
+	if got := readTestFile(t, windsurfConfig); !strings.Contains(got, "3031") {
+		t.Errorf("B's live entry was removed by A:\n%s", got)
+	}
+
+	if err := b.UnconfigureIDEs(); err != nil {
+		t.Fatalf("UnconfigureIDEs failed: %v", err)
+	}
+	if got := readTestFile(t, windsurfConfig); strings.Contains(got, "tinywasm-mcp") {
+		t.Errorf("B's entry was not removed:\n%s", got)
+	}
+}
+
+// TestConfigureIDEsReportAndDryRun verifies the report statuses and that dry runs write nothing
+func TestConfigureIDEsReportAndDryRun(t *testing.T) {
+	home := setTestHome(t)
+	cursorConfig := filepath.Join(home, ".cursor", "mcp.json")
+	os.MkdirAll(filepath.Dir(cursorConfig), 0755)
+	writeTestFile(t, cursorConfig, "{\n\t\"mcpServers\": {}\n}")
+
+	config := Config{Port: "3030", AppName: "TinyWasm", IDEDryRun: true}
+	report := NewHandler(config, nil, nil).ConfigureIDEs()
+
+	if !report.DryRun || !strings.HasSuffix(report.Summary(), "(dry run)") {
+		t.Errorf("Dry run not reported: %s", report.Summary())
+	}
+	if got := readTestFile(t, cursorConfig); got != "{\n\t\"mcpServers\": {}\n}" {
+		t.Errorf("Dry run modified the file:\n%s", got)
+	}
+	if _, err := os.Stat(filepath.Join(home, ".config", "Code")); !os.IsNotExist(err) {
+		t.Error("Dry run created the VS Code directory")
+	}
+
+	cursor := findResult(t, report, "cursor")
+	if cursor.Status != IDEUpdated || !strings.Contains(cursor.Diff, `+		"tinywasm-mcp": {`) {
+		t.Errorf("Unexpected Cursor result: %+v", cursor)
+	}
+	if zed := findResult(t, report, "zed"); zed.Status != IDESkipped || zed.Found || zed.Reason != "not installed" {
+		t.Errorf("Unexpected Zed result: %+v", zed)
+	}
+
+	// A real run applies the change, a second one finds nothing to do
+	config.IDEDryRun = false
+	NewHandler(config, nil, nil).ConfigureIDEs()
+	report = NewHandler(config, nil, nil).ConfigureIDEs()
+	if cursor := findResult(t, report, "cursor"); cursor.Status != IDEUnchanged {
+		t.Errorf("Expected unchanged Cursor config, got %+v", cursor)
+	}
+}
+
+func findResult(t *testing.T, report IDEReport, id string) IDEResult {
+	t.Helper()
+	for _, result := range report.Results {
+		if result.IDE == id {
+			return result
+		}
+	}
+	t.Fatalf("No result for %s in %+v", id, report.Results)
+	return IDEResult{}
+}
+
+// TestConfigureIDEsInstallDetection verifies directories are only created for detected or opted-in IDEs
+func TestConfigureIDEsInstallDetection(t *testing.T) {
+	home := setTestHome(t)
+
+	report := NewHandler(Config{Port: "3030", AppName: "TinyWasm"}, nil, nil).ConfigureIDEs()
+	if entries, _ := os.ReadDir(home); len(entries) != 0 {
+		t.Errorf("Nothing is installed, yet %d entries were created in home", len(entries))
+	}
+	if vsc := findResult(t, report, "vsc"); vsc.Status != IDESkipped || vsc.Reason != "not installed" {
+		t.Errorf("Unexpected VS Code result: %+v", vsc)
+	}
+
+	// A binary on PATH counts as installed
+	bin := t.TempDir()
+	writeTestFile(t, filepath.Join(bin, "cursor"), "#!/bin/sh\n")
+	os.Chmod(filepath.Join(bin, "cursor"), 0755)
+	t.Setenv("PATH", bin)
+
+	config := Config{Port: "3030", AppName: "TinyWasm", IDEOptIn: []string{"zed"}}
+	NewHandler(config, nil, nil).ConfigureIDEs()
+
+	for _, path := range []string{
+		filepath.Join(home, ".cursor", "mcp.json"),
+		filepath.Join(home, ".config", "zed", "settings.json"),
+	} {
+		if data := readTestFile(t, path); !strings.Contains(data, "tinywasm-mcp") {
+			t.Errorf("%s not configured:\n%s", path, data)
+		}
+	}
+	if _, err := os.Stat(filepath.Join(home, ".codeium")); !os.IsNotExist(err) {
+		t.Error("Windsurf is neither installed nor opted in")
+	}
+}
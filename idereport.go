@@ -0,0 +1,115 @@
+package mcpserve
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// IDEStatus is the outcome of configuring one IDE config file
+type IDEStatus string
+
+const (
+	IDECreated   IDEStatus = "created"   // Config file did not exist and was written
+	IDEUpdated   IDEStatus = "updated"   // Our entry was added or changed
+	IDEUnchanged IDEStatus = "unchanged" // Our entry was already up to date
+	IDESkipped   IDEStatus = "skipped"   // Nothing was written, see Reason
+	IDEFailed    IDEStatus = "error"     // Writing failed, see Err
+)
+
+// IDEResult reports what ConfigureIDEs did (or would do, in dry-run mode) for one config file
+type IDEResult struct {
+	IDE     string // IDEInfo.ID
+	Name    string // IDEInfo.Name
+	Profile string // "default", the profile folder name, or "workspace"
+	Path    string // Config file, or the config directory when the IDE was not found
+	Found   bool   // Whether the IDE was detected as installed
+	Status  IDEStatus
+	Reason  string // Why the file was skipped
+	Err     error  // Set when Status is IDEFailed
+	Diff    string // Unified diff of the change (empty when unchanged)
+}
+
+// IDEReport is returned by ConfigureIDEs
+type IDEReport struct {
+	DryRun  bool
+	Results []IDEResult
+}
+
+// Count returns the number of results with the given status
+func (r IDEReport) Count(status IDEStatus) int {
+	n := 0
+	for _, result := range r.Results {
+		if result.Status == status {
+			n++
+		}
+	}
+	return n
+}
+
+// Summary returns a one-line overview such as "2 updated, 1 unchanged, 9 skipped"
+func (r IDEReport) Summary() string {
+	parts := []string{}
+	for _, status := range []IDEStatus{IDECreated, IDEUpdated, IDEUnchanged, IDESkipped, IDEFailed} {
+		if n := r.Count(status); n > 0 {
+			label := string(status)
+			if status == IDEFailed && n > 1 {
+				label = "errors"
+			}
+			parts = append(parts, fmt.Sprintf("%d %s", n, label))
+		}
+	}
+	if len(parts) == 0 {
+		return "no IDE targets"
+	}
+	summary := strings.Join(parts, ", ")
+	if r.DryRun {
+		summary += " (dry run)"
+	}
+	return summary
+}
+
+// String describes the result on a single line
+func (r IDEResult) String() string {
+	line := fmt.Sprintf("%s [%s]: %s %s", r.Name, r.Profile, r.Status, r.Path)
+	switch {
+	case r.Err != nil:
+		line += ": " + r.Err.Error()
+	case r.Reason != "":
+		line += " (" + r.Reason + ")"
+	}
+	return line
+}
+
+// logIDEReport summarizes the report through the handler logger.
+// IDEs that are simply not installed are only counted, not listed.
+func (h *Handler) logIDEReport(report IDEReport) {
+	h.log("IDE configuration:", report.Summary())
+	for _, result := range report.Results {
+		if result.Status == IDESkipped && !result.Found {
+			continue
+		}
+		h.log(result.String())
+		if report.DryRun && result.Diff != "" {
+			h.log(result.Diff)
+		}
+	}
+}
+
+// profileName returns the IDE profile a config path belongs to
+func profileName(basePath, configPath string) string {
+	rel, err := filepath.Rel(filepath.Join(basePath, "profiles"), filepath.Dir(configPath))
+	if err != nil || rel == "." || strings.HasPrefix(rel, "..") {
+		return "default"
+	}
+	return rel
+}
+
+// permissionReason turns permission errors into a skip reason
+func permissionReason(err error) (string, bool) {
+	if os.IsPermission(err) {
+		return "permission denied", true
+	}
+	return "", false
+}
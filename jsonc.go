@@ -0,0 +1,557 @@
+package mcpserve
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// jsoncDocument is a JSON-with-comments file edited in place.
+// Edits only touch the bytes of the affected member, so comments, ordering,
+// formatting and unknown fields everywhere else are preserved.
+type jsoncDocument struct {
+	src   []byte       // Original text
+	code  []byte       // Same offsets as src, with comments blanked out
+	clean []byte       // Same as code, with trailing commas blanked out too
+	root  *jsoncObject // Parsed structure of clean
+}
+
+// jsoncObject records the offsets of an object and its members
+type jsoncObject struct {
+	open, close int // Offsets of '{' and '}'
+	members     []jsoncMember
+}
+
+// jsoncMember is a single "key": value pair
+type jsoncMember struct {
+	key        string
+	keyStart   int
+	valueStart int
+	valueEnd   int          // Offset just after the value
+	object     *jsoncObject // Set when the value is an object
+}
+
+// parseJSONC parses data, tolerating // and /* */ comments and trailing commas.
+// Empty input is treated as an empty object.
+func parseJSONC(data []byte) (*jsoncDocument, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		data = []byte("{}")
+	}
+
+	code, err := stripComments(data)
+	if err != nil {
+		return nil, err
+	}
+	clean := stripTrailingCommas(code)
+	if !json.Valid(clean) {
+		// Let encoding/json describe what is wrong
+		var v any
+		return nil, json.Unmarshal(clean, &v)
+	}
+
+	p := &jsoncParser{data: clean}
+	p.skipSpace()
+	if p.pos >= len(clean) || clean[p.pos] != '{' {
+		return nil, errors.New("top-level value is not an object")
+	}
+
+	return &jsoncDocument{src: data, code: code, clean: clean, root: p.parseObject()}, nil
+}
+
+// Bytes returns the current document text
+func (d *jsoncDocument) Bytes() []byte {
+	return d.src
+}
+
+// Get returns the value at path as plain JSON
+func (d *jsoncDocument) Get(path ...string) (json.RawMessage, bool) {
+	member, ok := d.lookup(path)
+	if !ok {
+		return nil, false
+	}
+	return json.RawMessage(d.clean[member.valueStart:member.valueEnd]), true
+}
+
+// Keys returns the member names of the object at path, in document order
+func (d *jsoncDocument) Keys(path ...string) []string {
+	obj := d.root
+	if len(path) > 0 {
+		member, ok := d.lookup(path)
+		if !ok || member.object == nil {
+			return nil
+		}
+		obj = member.object
+	}
+	keys := make([]string, len(obj.members))
+	for i, m := range obj.members {
+		keys[i] = m.key
+	}
+	return keys
+}
+
+// lookup finds the member at path
+func (d *jsoncDocument) lookup(path []string) (jsoncMember, bool) {
+	obj := d.root
+	for i, key := range path {
+		member, ok := obj.member(key)
+		if !ok {
+			return jsoncMember{}, false
+		}
+		if i == len(path)-1 {
+			return member, true
+		}
+		if member.object == nil {
+			return jsoncMember{}, false
+		}
+		obj = member.object
+	}
+	return jsoncMember{}, false
+}
+
+// Set stores value at path, creating missing intermediate objects.
+// An existing value is replaced in place; a new member is appended to its parent object.
+func (d *jsoncDocument) Set(path []string, value any) error {
+	if len(path) == 0 {
+		return errors.New("empty path")
+	}
+
+	obj := d.root
+	for i, key := range path {
+		member, ok := obj.member(key)
+		if !ok {
+			// Build the missing tail of the path as nested objects
+			var nested any = value
+			for j := len(path) - 1; j > i; j-- {
+				nested = map[string]any{path[j]: nested}
+			}
+			return d.insert(obj, key, nested)
+		}
+		if i == len(path)-1 {
+			return d.replace(member, value)
+		}
+		if member.object == nil {
+			return fmt.Errorf("%q is not an object", key)
+		}
+		obj = member.object
+	}
+	return nil
+}
+
+// Delete removes the member at path. Reports whether it existed.
+func (d *jsoncDocument) Delete(path ...string) (bool, error) {
+	if len(path) == 0 {
+		return false, errors.New("empty path")
+	}
+
+	obj := d.root
+	if len(path) > 1 {
+		parent, ok := d.lookup(path[:len(path)-1])
+		if !ok || parent.object == nil {
+			return false, nil
+		}
+		obj = parent.object
+	}
+
+	key := path[len(path)-1]
+	index := -1
+	for i, m := range obj.members {
+		if m.key == key {
+			index = i
+		}
+	}
+	if index < 0 {
+		return false, nil
+	}
+	member := obj.members[index]
+
+	// Remove the member together with its own line when it sits alone on it
+	start := member.keyStart
+	if lineStart := d.lineStart(start); isBlank(d.src[lineStart:start]) {
+		start = lineStart
+	}
+	end := member.valueEnd
+	comma := d.commaAfter(end, obj.close)
+	if comma >= 0 {
+		end = comma + 1
+	}
+	if start == d.lineStart(start) {
+		// Take the rest of the line, including a comment describing the member
+		if lineEnd := d.restOfLine(end); lineEnd >= 0 {
+			end = d.lineEnd(lineEnd)
+			if end < len(d.src) {
+				end++ // '\n'
+			}
+		}
+	}
+
+	edits := []jsoncEdit{{start, end, nil}}
+
+	// An object left without members collapses to {} unless comments remain inside it
+	if len(obj.members) == 1 && isBlank(d.src[obj.open+1:start]) && isBlank(d.src[end:obj.close]) {
+		edits = []jsoncEdit{{obj.open + 1, obj.close, nil}}
+	}
+
+	// The previous member now ends the object: drop its separating comma
+	if comma < 0 && index > 0 {
+		if prev := d.commaAfter(obj.members[index-1].valueEnd, member.keyStart); prev >= 0 {
+			edits = append(edits, jsoncEdit{prev, prev + 1, nil})
+		}
+	}
+
+	return true, d.apply(edits...)
+}
+
+// replace swaps the value of an existing member
+func (d *jsoncDocument) replace(member jsoncMember, value any) error {
+	indent := d.indentOf(member.keyStart)
+	rendered, err := json.MarshalIndent(value, indent, d.indentUnit())
+	if err != nil {
+		return err
+	}
+	return d.apply(jsoncEdit{member.valueStart, member.valueEnd, d.withNewlines(rendered)})
+}
+
+// insert appends a new member to obj, following the object's existing layout
+func (d *jsoncDocument) insert(obj *jsoncObject, key string, value any) error {
+	keyJSON, _ := json.Marshal(key)
+	unit := d.indentUnit()
+	nl := d.newline()
+
+	// Compact objects written on a single line stay on a single line
+	compact := d.lineStart(obj.close) == d.lineStart(obj.open) && len(obj.members) > 0
+
+	var indent string
+	switch {
+	case len(obj.members) > 0:
+		indent = d.indentOf(obj.members[0].keyStart)
+	default:
+		indent = d.indentOf(obj.open) + unit
+	}
+
+	var rendered []byte
+	var err error
+	if compact {
+		rendered, err = json.Marshal(value)
+	} else {
+		rendered, err = json.MarshalIndent(value, indent, unit)
+	}
+	if err != nil {
+		return err
+	}
+	entry := append(append(keyJSON, ": "...), d.withNewlines(rendered)...)
+
+	if len(obj.members) == 0 {
+		// Rewrite the interior of an empty object, keeping any comments inside it
+		interior := bytes.TrimRight(d.src[obj.open+1:obj.close], " \t\r\n")
+		text := "{" + string(interior) + nl + indent + string(entry) + nl + d.indentOf(obj.open) + "}"
+		return d.apply(jsoncEdit{obj.open, obj.close + 1, []byte(text)})
+	}
+
+	last := obj.members[len(obj.members)-1]
+	at := last.valueEnd
+	separator := ","
+	if comma := d.commaAfter(last.valueEnd, obj.close); comma >= 0 {
+		// Trailing comma already present
+		at, separator = comma+1, ""
+	}
+
+	if compact {
+		return d.apply(jsoncEdit{at, at, []byte(separator + " " + string(entry))})
+	}
+
+	// Keep a comment trailing the previous member on that member's line
+	line := nl + indent + string(entry)
+	if rest := d.restOfLine(at); rest > at {
+		return d.apply(jsoncEdit{at, at, []byte(separator)}, jsoncEdit{rest, rest, []byte(line)})
+	}
+	return d.apply(jsoncEdit{at, at, []byte(separator + line)})
+}
+
+// restOfLine returns the end of the line containing offset when only whitespace
+// and comments follow it, or -1 otherwise (also when a block comment continues on the next line).
+func (d *jsoncDocument) restOfLine(offset int) int {
+	end := d.lineEnd(offset)
+	if !isBlank(d.code[offset:end]) || opensBlockComment(d.src[offset:end]) {
+		return -1
+	}
+	if end > offset && d.src[end-1] == '\r' {
+		end--
+	}
+	return end
+}
+
+// opensBlockComment reports whether a comment-only line segment starts a /* comment it doesn't close
+func opensBlockComment(line []byte) bool {
+	for i := 0; i+1 < len(line); i++ {
+		switch {
+		case line[i] == '/' && line[i+1] == '/':
+			return false // The rest is a line comment
+		case line[i] == '/' && line[i+1] == '*':
+			end := bytes.Index(line[i+2:], []byte("*/"))
+			if end < 0 {
+				return true
+			}
+			i += 2 + end + 1
+		}
+	}
+	return false
+}
+
+// newline returns the line ending used by the document ("\r\n" or "\n")
+func (d *jsoncDocument) newline() string {
+	if bytes.Contains(d.src, []byte("\r\n")) {
+		return "\r\n"
+	}
+	return "\n"
+}
+
+// withNewlines converts the \n line endings of marshalled JSON to the document's
+func (d *jsoncDocument) withNewlines(rendered []byte) []byte {
+	if nl := d.newline(); nl != "\n" {
+		return bytes.ReplaceAll(rendered, []byte("\n"), []byte(nl))
+	}
+	return rendered
+}
+
+// jsoncEdit replaces src[start:end] with text
+type jsoncEdit struct {
+	start, end int
+	text       []byte
+}
+
+// apply performs non-overlapping edits and re-parses the document
+func (d *jsoncDocument) apply(edits ...jsoncEdit) error {
+	out := append([]byte(nil), d.src...)
+	// Apply from the back so earlier offsets stay valid
+	sort.Slice(edits, func(i, j int) bool { return edits[i].start > edits[j].start })
+	for _, e := range edits {
+		out = append(out[:e.start], append(append([]byte(nil), e.text...), out[e.end:]...)...)
+	}
+
+	updated, err := parseJSONC(out)
+	if err != nil {
+		return fmt.Errorf("edit produced invalid JSON: %w", err)
+	}
+	*d = *updated
+	return nil
+}
+
+// commaAfter returns the offset of the comma following a value that ends at from, or -1
+func (d *jsoncDocument) commaAfter(from, limit int) int {
+	for i := from; i < limit; i++ {
+		switch d.code[i] {
+		case ',':
+			return i
+		case ' ', '\t', '\n', '\r':
+		default:
+			return -1
+		}
+	}
+	return -1
+}
+
+// indentUnit guesses the indentation unit of the document (defaults to a tab like VS Code)
+func (d *jsoncDocument) indentUnit() string {
+	for _, member := range d.root.members {
+		if indent := d.indentOf(member.keyStart); indent != "" && isBlank([]byte(indent)) {
+			return indent
+		}
+	}
+	return "\t"
+}
+
+// indentOf returns the leading whitespace of the line containing offset
+func (d *jsoncDocument) indentOf(offset int) string {
+	start := d.lineStart(offset)
+	end := start
+	for end < len(d.src) && (d.src[end] == ' ' || d.src[end] == '\t') {
+		end++
+	}
+	return string(d.src[start:end])
+}
+
+func (d *jsoncDocument) lineStart(offset int) int {
+	return bytes.LastIndexByte(d.src[:offset], '\n') + 1
+}
+
+func (d *jsoncDocument) lineEnd(offset int) int {
+	if i := bytes.IndexByte(d.src[offset:], '\n'); i >= 0 {
+		return offset + i
+	}
+	return len(d.src)
+}
+
+// member finds a member by key; the last duplicate wins like in encoding/json
+func (o *jsoncObject) member(key string) (jsoncMember, bool) {
+	for i := len(o.members) - 1; i >= 0; i-- {
+		if o.members[i].key == key {
+			return o.members[i], true
+		}
+	}
+	return jsoncMember{}, false
+}
+
+func isBlank(b []byte) bool {
+	return len(bytes.TrimSpace(b)) == 0
+}
+
+// stripComments blanks // and /* */ comments while keeping offsets and newlines
+func stripComments(data []byte) ([]byte, error) {
+	out := append([]byte(nil), data...)
+
+	for i := 0; i < len(out); i++ {
+		switch {
+		case out[i] == '"':
+			end, err := skipString(out, i)
+			if err != nil {
+				return nil, err
+			}
+			i = end - 1
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '/':
+			for ; i < len(out) && out[i] != '\n'; i++ {
+				out[i] = ' '
+			}
+		case out[i] == '/' && i+1 < len(out) && out[i+1] == '*':
+			end := bytes.Index(out[i+2:], []byte("*/"))
+			if end < 0 {
+				return nil, errors.New("unterminated block comment")
+			}
+			for j := i; j < i+2+end+2; j++ {
+				if out[j] != '\n' && out[j] != '\r' {
+					out[j] = ' '
+				}
+			}
+			i += 2 + end + 1
+		}
+	}
+	return out, nil
+}
+
+// stripTrailingCommas blanks commas directly followed by '}' or ']' in comment-free data
+func stripTrailingCommas(code []byte) []byte {
+	out := append([]byte(nil), code...)
+	lastComma := -1 // Offset of a comma not yet followed by a value
+
+	for i := 0; i < len(out); i++ {
+		switch c := out[i]; c {
+		case '"':
+			end, _ := skipString(out, i)
+			i = end - 1
+			lastComma = -1
+		case ',':
+			lastComma = i
+		case '}', ']':
+			if lastComma >= 0 {
+				out[lastComma] = ' '
+			}
+			lastComma = -1
+		case ' ', '\t', '\n', '\r':
+		default:
+			lastComma = -1
+		}
+	}
+	return out
+}
+
+// skipString returns the offset just after the string starting at data[start]
+func skipString(data []byte, start int) (int, error) {
+	for i := start + 1; i < len(data); i++ {
+		switch data[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1, nil
+		}
+	}
+	return 0, errors.New("unterminated string")
+}
+
+// jsoncParser walks valid JSON recording object and member offsets
+type jsoncParser struct {
+	data []byte
+	pos  int
+}
+
+func (p *jsoncParser) skipSpace() {
+	for p.pos < len(p.data) {
+		switch p.data[p.pos] {
+		case ' ', '\t', '\n', '\r':
+			p.pos++
+		default:
+			return
+		}
+	}
+}
+
+// parseObject parses the object starting at p.pos
+func (p *jsoncParser) parseObject() *jsoncObject {
+	obj := &jsoncObject{open: p.pos}
+	p.pos++ // '{'
+
+	for {
+		p.skipSpace()
+		switch p.data[p.pos] {
+		case '}':
+			obj.close = p.pos
+			p.pos++
+			return obj
+		case ',':
+			p.pos++
+			continue
+		}
+
+		// Key
+		keyStart := p.pos
+		keyEnd, _ := skipString(p.data, keyStart)
+		var key string
+		json.Unmarshal(p.data[keyStart:keyEnd], &key)
+		p.pos = keyEnd
+
+		// Colon
+		p.skipSpace()
+		p.pos++
+		p.skipSpace()
+
+		// Value
+		member := jsoncMember{key: key, keyStart: keyStart, valueStart: p.pos}
+		member.object = p.parseValue()
+		member.valueEnd = p.pos
+		obj.members = append(obj.members, member)
+	}
+}
+
+// parseValue skips the value at p.pos, returning its structure when it is an object
+func (p *jsoncParser) parseValue() *jsoncObject {
+	switch p.data[p.pos] {
+	case '{':
+		return p.parseObject()
+	case '[':
+		p.pos++
+		for {
+			p.skipSpace()
+			switch p.data[p.pos] {
+			case ']':
+				p.pos++
+				return nil
+			case ',':
+				p.pos++
+			default:
+				p.parseValue()
+			}
+		}
+	case '"':
+		p.pos, _ = skipString(p.data, p.pos)
+	default:
+		// Numbers, true, false, null
+		for p.pos < len(p.data) {
+			switch p.data[p.pos] {
+			case ',', '}', ']', ' ', '\t', '\n', '\r':
+				return nil
+			}
+			p.pos++
+		}
+	}
+	return nil
+}
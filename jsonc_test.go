@@ -0,0 +1,235 @@
+package mcpserve
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+const vscodeMCPWithComments = `{
+	// Servers managed by hand
+	"servers": {
+		"git": {
+			"command": "uvx",
+			"args": ["mcp-server-git"],
+			"env": {"GIT_DIR": "/src"}, // keep me
+			"envFile": "${workspaceFolder}/.env",
+		},
+		/* remote one */
+		"remote": {
+			"url": "https://example.com/mcp",
+			"headers": {"Authorization": "Bearer ${input:token}"}
+		}, // trailing comma
+	},
+	"inputs": [],
+}
+`
+
+// TestJSONCSetPreservesDocument verifies inserting a server keeps comments and unknown fields
+func TestJSONCSetPreservesDocument(t *testing.T) {
+	doc, err := parseJSONC([]byte(vscodeMCPWithComments))
+	if err != nil {
+		t.Fatalf("parseJSONC failed: %v", err)
+	}
+
+	entry := mcpServerConfig{URL: "http://localhost:3030/mcp", Type: "http"}
+	if err := doc.Set([]string{"servers", "tinywasm-mcp"}, entry); err != nil {
+		t.Fatalf("Set failed: %v", err)
+	}
+	out := string(doc.Bytes())
+
+	// Everything that was there before is still there, byte for byte
+	before := vscodeMCPWithComments[:strings.Index(vscodeMCPWithComments, "}, // trailing comma")]
+	if !strings.HasPrefix(out, before) {
+		t.Errorf("Existing content was modified:\n%s", out)
+	}
+	for _, keep := range []string{"// keep me", "/* remote one */", "// trailing comma", `"envFile"`, `"headers"`} {
+		if !strings.Contains(out, keep) {
+			t.Errorf("Lost %q:\n%s", keep, out)
+		}
+	}
+
+	raw, ok := doc.Get("servers", "tinywasm-mcp")
+	if !ok || !sameJSON(raw, entry) {
+		t.Errorf("Entry not stored correctly: %s", raw)
+	}
+	if keys := doc.Keys("servers"); strings.Join(keys, ",") != "git,remote,tinywasm-mcp" {
+		t.Errorf("Unexpected server order: %v", keys)
+	}
+
+	// Replacing keeps the same position and stays parseable
+	entry.URL = "http://localhost:4040/mcp"
+	if err := doc.Set([]string{"servers", "tinywasm-mcp"}, entry); err != nil {
+		t.Fatalf("Replace failed: %v", err)
+	}
+	if raw, _ := doc.Get("servers", "tinywasm-mcp"); !strings.Contains(string(raw), "4040") {
+		t.Errorf("Entry not replaced: %s", raw)
+	}
+	if strings.Count(string(doc.Bytes()), "tinywasm-mcp") != 1 {
+		t.Errorf("Entry duplicated:\n%s", doc.Bytes())
+	}
+}
+
+// TestJSONCInsertLayouts verifies new members follow the surrounding layout
+func TestJSONCInsertLayouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  []string
+		want  string
+	}{
+		{
+			name:  "empty file",
+			input: "",
+			path:  []string{"servers", "a"},
+			want:  "{\n\t\"servers\": {\n\t\t\"a\": 1\n\t}\n}",
+		},
+		{
+			name:  "empty object",
+			input: "{\n  \"servers\": {}\n}",
+			path:  []string{"servers", "a"},
+			want:  "{\n  \"servers\": {\n    \"a\": 1\n  }\n}",
+		},
+		{
+			name:  "compact object",
+			input: `{"servers": {"b": 2}}`,
+			path:  []string{"servers", "a"},
+			want:  `{"servers": {"b": 2, "a": 1}}`,
+		},
+		{
+			name:  "comment after last member",
+			input: "{\n\t\"b\": 2 // note\n}",
+			path:  []string{"a"},
+			want:  "{\n\t\"b\": 2, // note\n\t\"a\": 1\n}",
+		},
+		{
+			name:  "block comment after last member",
+			input: "{\n\t\"b\": 2 /* c */\n}",
+			path:  []string{"a"},
+			want:  "{\n\t\"b\": 2, /* c */\n\t\"a\": 1\n}",
+		},
+		{
+			name:  "block comment continuing on the next line",
+			input: "{\n\t\"b\": 2 /* c\n\t d */\n}",
+			path:  []string{"a"},
+			want:  "{\n\t\"b\": 2,\n\t\"a\": 1 /* c\n\t d */\n}",
+		},
+		{
+			name:  "CRLF line endings",
+			input: "{\r\n\t\"b\": 2\r\n}",
+			path:  []string{"servers", "a"},
+			want:  "{\r\n\t\"b\": 2,\r\n\t\"servers\": {\r\n\t\t\"a\": 1\r\n\t}\r\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseJSONC([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseJSONC failed: %v", err)
+			}
+			if err := doc.Set(tt.path, 1); err != nil {
+				t.Fatalf("Set failed: %v", err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("got:\n%s\nwant:\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestJSONCDelete verifies members are removed with their separators
+func TestJSONCDelete(t *testing.T) {
+	input := "{\n\t\"a\": 1,\n\t\"b\": 2, // ours\n\t\"c\": 3\n}"
+
+	doc, _ := parseJSONC([]byte(input))
+	if ok, err := doc.Delete("b"); !ok || err != nil {
+		t.Fatalf("Delete failed: ok=%v err=%v", ok, err)
+	}
+	if got, want := string(doc.Bytes()), "{\n\t\"a\": 1,\n\t\"c\": 3\n}"; got != want {
+		t.Errorf("got:\n%s\nwant:\n%s", got, want)
+	}
+
+	if ok, _ := doc.Delete("c"); !ok {
+		t.Fatal("Delete of last member failed")
+	}
+	var v map[string]any
+	if err := json.Unmarshal(doc.clean, &v); err != nil || len(v) != 1 {
+		t.Errorf("Unexpected document after delete (%v):\n%s", err, doc.Bytes())
+	}
+
+	if ok, _ := doc.Delete("missing"); ok {
+		t.Error("Delete of missing member reported success")
+	}
+}
+
+// TestJSONCDeleteLayouts verifies deletions leave no stray comments or blank objects behind
+func TestJSONCDeleteLayouts(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		path  []string
+		want  string
+	}{
+		{
+			name:  "last member after a block comment",
+			input: "{\n\t\"b\": 2, /* c */\n\t\"a\": 1\n}",
+			path:  []string{"a"},
+			want:  "{\n\t\"b\": 2 /* c */\n}",
+		},
+		{
+			name:  "member with a trailing block comment",
+			input: "{\n\t\"b\": 2,\n\t\"a\": 1 /* ours */\n}",
+			path:  []string{"a"},
+			want:  "{\n\t\"b\": 2\n}",
+		},
+		{
+			name:  "only member",
+			input: "{\n\t\"servers\": {\n\t\t\"a\": 1\n\t}\n}",
+			path:  []string{"servers", "a"},
+			want:  "{\n\t\"servers\": {}\n}",
+		},
+		{
+			name:  "only member next to a comment",
+			input: "{\n\t\"servers\": {\n\t\t// mine\n\t\t\"a\": 1\n\t}\n}",
+			path:  []string{"servers", "a"},
+			want:  "{\n\t\"servers\": {\n\t\t// mine\n\t}\n}",
+		},
+		{
+			name:  "CRLF line endings",
+			input: "{\r\n\t\"b\": 2,\r\n\t\"a\": 1\r\n}",
+			path:  []string{"a"},
+			want:  "{\r\n\t\"b\": 2\r\n}",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			doc, err := parseJSONC([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("parseJSONC failed: %v", err)
+			}
+			if ok, err := doc.Delete(tt.path...); !ok || err != nil {
+				t.Fatalf("Delete failed: ok=%v err=%v", ok, err)
+			}
+			if got := string(doc.Bytes()); got != tt.want {
+				t.Errorf("got:\n%q\nwant:\n%q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestUpdateMCPConfigJSONC verifies VS Code files with comments are updated instead of skipped
+func TestUpdateMCPConfigJSONC(t *testing.T) {
+	configPath := t.TempDir() + "/mcp.json"
+	writeTestFile(t, configPath, vscodeMCPWithComments)
+
+	if _, _, err := updateMCPConfig(configPath, defaultIDEs()[0], "TinyWasm", "3030", false); err != nil {
+		t.Fatalf("updateMCPConfig failed: %v", err)
+	}
+
+	data := readTestFile(t, configPath)
+	if !strings.Contains(data, "// keep me") || !strings.Contains(data, `"tinywasm-mcp"`) {
+		t.Errorf("Unexpected config:\n%s", data)
+	}
+}
@@ -0,0 +1,157 @@
+package mcpserve
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// ToolProblem is an invalid piece of tool metadata found at registration
+type ToolProblem struct {
+	Handler string // Handler type, e.g. "*client.Handler"
+	Tool    string // Tool name, "#<index>" when empty, "" when the whole handler failed to load
+	Problem string
+}
+
+func (p ToolProblem) String() string {
+	if p.Tool == "" {
+		return fmt.Sprintf("%s: %s", p.Handler, p.Problem)
+	}
+	return fmt.Sprintf("%s: tool %s: %s", p.Handler, p.Tool, p.Problem)
+}
+
+// ToolValidationError is returned by Start in Config.StrictTools mode
+type ToolValidationError struct {
+	Problems []ToolProblem
+}
+
+func (e *ToolValidationError) Error() string {
+	lines := make([]string, len(e.Problems))
+	for i, p := range e.Problems {
+		lines[i] = "  " + p.String()
+	}
+	return fmt.Sprintf("invalid MCP tool metadata (%d problems):\n%s", len(e.Problems), strings.Join(lines, "\n"))
+}
+
+// ValidateTools loads the tools of all handlers and reports every metadata problem:
+// empty or invalid names, missing Execute, duplicate names (after namespacing), unknown
+// parameter types and defaults that do not match their type. Tools with problems are never registered.
+// Config.Downstreams entries without a valid Prefix, Command or URL are reported too.
+func (h *Handler) ValidateTools() []ToolProblem {
+	return h.loadTools(func(any, []ToolMetadata) {})
+}
+
+// loadTools calls register with the valid tools of each handler, in order, and returns the problems found
+func (h *Handler) loadTools(register func(handler any, tools []ToolMetadata)) []ToolProblem {
+	var problems []ToolProblem
+	seen := map[string]string{} // Tool name -> handler type that registered it
+
+	sep, problem := h.namespaceSeparator()
+	if problem != "" {
+		problems = append(problems, ToolProblem{Handler: "Config", Problem: problem})
+	}
+	for i, d := range h.config.Downstreams {
+		if problem := d.check(); problem != "" {
+			problems = append(problems, ToolProblem{Handler: fmt.Sprintf("Config.Downstreams[%d]", i), Problem: problem})
+		}
+	}
+
+	for _, handler := range h.toolHandlers {
+		if handler == nil {
+			continue
+		}
+		handlerType := fmt.Sprintf("%T", handler)
+
+		tools, err := h.mcpToolsFromHandler(handler)
+		if err != nil {
+			problems = append(problems, ToolProblem{Handler: handlerType, Problem: err.Error()})
+			continue
+		}
+
+		prefix := h.toolPrefix(handler)
+		valid := []ToolMetadata{}
+		for i, tool := range tools {
+			tool.Name = namespaced(prefix, sep, tool.Name)
+			toolProblems := validateTool(tool)
+			if first, dup := seen[tool.Name]; dup && tool.Name != "" {
+				toolProblems = append(toolProblems, "duplicate tool name, already registered by "+first)
+			}
+			label := tool.Name
+			if label == "" {
+				label = fmt.Sprintf("#%d", i)
+			}
+			for _, problem := range toolProblems {
+				problems = append(problems, ToolProblem{Handler: handlerType, Tool: label, Problem: problem})
+			}
+			if len(toolProblems) == 0 {
+				seen[tool.Name] = handlerType
+				valid = append(valid, tool)
+			}
+		}
+		register(handler, valid)
+	}
+	return problems
+}
+
+// validateTool lists the problems of one tool's own metadata
+func validateTool(tool ToolMetadata) []string {
+	var problems []string
+	if strings.TrimSpace(tool.Name) == "" {
+		problems = append(problems, "empty name")
+	} else if problem := checkToolName(tool.Name); problem != "" {
+		problems = append(problems, problem)
+	}
+	if tool.Execute == nil {
+		problems = append(problems, "nil Execute")
+	}
+
+	params := map[string]bool{}
+	for i, param := range tool.Parameters {
+		label := fmt.Sprintf("parameter %q", param.Name)
+		switch {
+		case param.Name == "":
+			problems = append(problems, fmt.Sprintf("parameter %d: empty name", i))
+			continue
+		case params[param.Name]:
+			problems = append(problems, label+": duplicate name")
+		}
+		params[param.Name] = true
+
+		switch param.Type {
+		case "string", "number", "boolean":
+		default:
+			problems = append(problems, fmt.Sprintf("%s: unknown type %q, expected string, number or boolean", label, param.Type))
+			continue
+		}
+
+		if len(param.EnumValues) > 0 && param.Type != "string" {
+			problems = append(problems, label+": EnumValues require type string")
+		}
+		if param.Default == nil {
+			continue
+		}
+		if !defaultMatchesType(param.Default, param.Type) {
+			problems = append(problems, fmt.Sprintf("%s: default %#v (%T) does not match type %s", label, param.Default, param.Default, param.Type))
+		} else if s, ok := param.Default.(string); ok && len(param.EnumValues) > 0 && !slices.Contains(param.EnumValues, s) {
+			problems = append(problems, fmt.Sprintf("%s: default %q is not one of EnumValues", label, s))
+		}
+	}
+	return problems
+}
+
+// defaultMatchesType reports whether a Default value fits the parameter type, i.e. whether
+// buildMCPTool publishes it in the schema
+func defaultMatchesType(value any, paramType string) bool {
+	switch paramType {
+	case "string":
+		_, ok := value.(string)
+		return ok
+	case "boolean":
+		_, ok := value.(bool)
+		return ok
+	case "number":
+		_, ok := numberValue(value)
+		return ok
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+)
+
+// badHandler declares every kind of invalid tool metadata
+type badHandler struct{}
+
+func (b *badHandler) GetMCPToolsMetadata() []ToolMetadata {
+	noop := func(map[string]any) {}
+	return []ToolMetadata{
+		{Name: "", Execute: noop},
+		{Name: "no_execute"},
+		{Name: "test_tool", Execute: noop}, // Also declared by mockHandler
+		{Name: "bad_params", Execute: noop, Parameters: []ParameterMetadata{
+			{Name: "count", Type: "integer"},
+			{Name: "level", Type: "number", Default: "high"},
+			{Name: "mode", Type: "string", EnumValues: []string{"a", "b"}, Default: "c"},
+		}},
+		{Name: "good", Execute: noop, Parameters: []ParameterMetadata{
+			{Name: "retries", Type: "number", Default: 3},
+			{Name: "verbose", Type: "boolean", Default: true},
+		}},
+	}
+}
+
+func TestValidateTools(t *testing.T) {
+	handler := NewHandler(Config{}, []any{&mockHandler{}, &badHandler{}, struct{}{}}, nil)
+
+	got := []string{}
+	for _, problem := range handler.ValidateTools() {
+		got = append(got, problem.String())
+	}
+	want := []string{
+		`*mcpserve.badHandler: tool #0: empty name`,
+		`*mcpserve.badHandler: tool no_execute: nil Execute`,
+		`*mcpserve.badHandler: tool test_tool: duplicate tool name, already registered by *mcpserve.mockHandler`,
+		`*mcpserve.badHandler: tool bad_params: parameter "count": unknown type "integer", expected string, number or boolean`,
+		`*mcpserve.badHandler: tool bad_params: parameter "level": default "high" (string) does not match type number`,
+		`*mcpserve.badHandler: tool bad_params: parameter "mode": default "c" is not one of EnumValues`,
+		`struct {}: method GetMCPToolsMetadata not found on handler`,
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("Unexpected problems:\n%s", strings.Join(got, "\n"))
+	}
+
+	// Lenient mode registers only the valid tools, keeping the first of duplicates
+	s := handler.MCPServer()
+	if len(handler.tools) != 2 || handler.tools["good"].Name == "" {
+		t.Errorf("Expected test_tool and good to be registered, got %v", handler.tools)
+	}
+
+	// Defaults that pass validation are published in tools/list
+	properties := s.ListTools()["good"].Tool.InputSchema.Properties
+	if got := properties["retries"].(map[string]any)["default"]; got != 3.0 {
+		t.Errorf("Expected number default 3 in the schema, got %#v", got)
+	}
+	if got := properties["verbose"].(map[string]any)["default"]; got != true {
+		t.Errorf("Expected boolean default true in the schema, got %#v", got)
+	}
+}
+
+func TestStartStrictTools(t *testing.T) {
+	handler := NewHandler(Config{Port: "0", StrictTools: true}, []any{&badHandler{}}, nil)
+
+	err := handler.Start(context.Background())
+	var validationErr *ToolValidationError
+	if !errors.As(err, &validationErr) || len(validationErr.Problems) != 5 {
+		t.Fatalf("Expected a validation error with 5 problems, got %v", err)
+	}
+	if handler.Addr() != nil {
+		t.Error("No port should be bound when validation fails")
+	}
+	select {
+	case <-handler.Ready():
+	default:
+		t.Error("Ready should be closed when Start fails")
+	}
+}
@@ -0,0 +1,85 @@
+package mcpserve
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// listen binds the MCP port synchronously, falling back to Config.PortRange and then to an
+// OS-assigned port (Config.PortAuto) when the configured port is taken.
+func (h *Handler) listen() (net.Listener, error) {
+	candidates, err := h.candidatePorts()
+	if err != nil {
+		return nil, err
+	}
+
+	var errs []error
+	for _, port := range candidates {
+		listener, err := net.Listen("tcp", ":"+port)
+		if err == nil {
+			return listener, nil
+		}
+		errs = append(errs, err)
+		h.log(fmt.Sprintf("MCP port %s unavailable: %v", port, err))
+	}
+	return nil, fmt.Errorf("no MCP port available: %w", errors.Join(errs...))
+}
+
+// candidatePorts lists the ports to try, in order
+func (h *Handler) candidatePorts() ([]string, error) {
+	ports := []string{h.config.Port}
+
+	if h.config.PortRange != "" {
+		first, last, err := parsePortRange(h.config.PortRange)
+		if err != nil {
+			return nil, err
+		}
+		for port := first; port <= last; port++ {
+			if p := strconv.Itoa(port); p != h.config.Port {
+				ports = append(ports, p)
+			}
+		}
+	}
+
+	if h.config.PortAuto {
+		ports = append(ports, "0") // Let the OS pick a free port
+	}
+	return ports, nil
+}
+
+// parsePortRange parses "3031-3040" or a single port "3031"
+func parsePortRange(spec string) (first, last int, err error) {
+	low, high, isRange := strings.Cut(spec, "-")
+	if !isRange {
+		high = low
+	}
+
+	first, err = strconv.Atoi(strings.TrimSpace(low))
+	if err == nil {
+		last, err = strconv.Atoi(strings.TrimSpace(high))
+	}
+	if err != nil || first < 1 || last > 65535 || first > last {
+		return 0, 0, fmt.Errorf("invalid PortRange %q, expected e.g. \"3031-3040\"", spec)
+	}
+	return first, last, nil
+}
+
+// Port returns the port the MCP server is bound to, or Config.Port before Start has bound it
+func (h *Handler) Port() string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.addr == nil {
+		return h.config.Port
+	}
+	return strconv.Itoa(h.addr.(*net.TCPAddr).Port)
+}
+
+// Addr returns the address the MCP server is bound to, or nil before Start has bound it
+func (h *Handler) Addr() net.Addr {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.addr
+}
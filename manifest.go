@@ -0,0 +1,149 @@
+package mcpserve
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// ideManifest tracks every config entry ConfigureIDEs created or changed for this app, so that
+// UnconfigureIDEs removes exactly those and nothing else. Instances of the app on other ports
+// (other checkouts) share it; each only removes the entries pointing at its own URL.
+// Stored in <user config dir>/mcpserve/<server id>.json
+type ideManifest struct {
+	Entries []ideManifestEntry `json:"entries"`
+}
+
+// ideManifestEntry is one server entry written into one IDE config file
+type ideManifestEntry struct {
+	Path       string          `json:"path"`
+	ServersKey string          `json:"serversKey"`
+	URL        string          `json:"url"`   // Server URL the entry points at, identifies the instance
+	Entry      json.RawMessage `json:"entry"` // Last value written, compared before removal
+}
+
+// manifestPath returns where the manifest of serverID is stored
+func manifestPath(serverID string) (string, error) {
+	return userConfigPath("mcpserve", serverID+".json")
+}
+
+// updateManifest loads the manifest of serverID, lets fn modify it and saves it under lock
+// An empty manifest is deleted.
+func updateManifest(serverID string, fn func(m *ideManifest)) error {
+	path, err := manifestPath(serverID)
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+
+	unlock, err := lockFile(path)
+	if err != nil {
+		return err
+	}
+	defer unlock()
+
+	var manifest ideManifest
+	data, err := os.ReadFile(path)
+	if err == nil {
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return fmt.Errorf("invalid manifest %s: %w", path, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	fn(&manifest)
+
+	if len(manifest.Entries) == 0 {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return nil
+	}
+
+	updated, err := json.MarshalIndent(manifest, "", "\t")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(path, updated, 0644)
+}
+
+// set adds or replaces the record for e.Path
+func (m *ideManifest) set(e ideManifestEntry) {
+	for i := range m.Entries {
+		if m.Entries[i].Path == e.Path {
+			m.Entries[i] = e
+			return
+		}
+	}
+	m.Entries = append(m.Entries, e)
+}
+
+// configureIDEFile writes our entry into one IDE config file and records it in the manifest
+// Nothing is written or recorded in dry-run mode.
+func (h *Handler) configureIDEFile(configPath string, ide IDEInfo, profile string) IDEResult {
+	result := IDEResult{IDE: ide.ID, Name: ide.Name, Profile: profile, Path: configPath, Found: true}
+
+	status, diff, err := updateMCPConfig(configPath, ide, h.config.AppName, h.Port(), h.config.IDEDryRun)
+	result.Status, result.Diff = status, diff
+	if err != nil {
+		if reason, ok := permissionReason(err); ok {
+			result.Status, result.Reason = IDESkipped, reason
+			return result
+		}
+		result.Err = err
+		return result
+	}
+	if h.config.IDEDryRun || status == IDEUnchanged {
+		return result // An entry we didn't write, e.g. added by hand, is not ours to remove
+	}
+
+	url := mcpServerURL(h.Port())
+	entry, err := json.Marshal(ide.Entry(url))
+	if err == nil {
+		err = updateManifest(mcpServerID(h.config.AppName), func(m *ideManifest) {
+			m.set(ideManifestEntry{Path: configPath, ServersKey: ide.ServersKey, URL: url, Entry: entry})
+		})
+	}
+	if err != nil {
+		// The IDE is configured, but UnconfigureIDEs won't know about it
+		h.log(fmt.Sprintf("Warning: could not record %s for cleanup: %v", configPath, err))
+	}
+	return result
+}
+
+// UnconfigureIDEs removes the server entries ConfigureIDEs created or changed from every IDE config
+// file, user-level and workspace alike. Only entries pointing at this server's port are removed;
+// entries the user or another instance has edited since are left in place.
+func (h *Handler) UnconfigureIDEs() error {
+	serverID := mcpServerID(h.config.AppName)
+	url := mcpServerURL(h.Port())
+	var errs []error
+
+	err := updateManifest(serverID, func(m *ideManifest) {
+		kept := []ideManifestEntry{}
+		for _, e := range m.Entries {
+			if e.URL != url {
+				kept = append(kept, e) // Written by an instance on another port
+				continue
+			}
+			removed, err := removeMCPConfigEntry(e.Path, e.ServersKey, serverID, e.Entry)
+			if err != nil {
+				// Keep the record so a later call can retry
+				errs = append(errs, err)
+				kept = append(kept, e)
+				continue
+			}
+			if removed {
+				h.log(fmt.Sprintf("Removed %s from %s", serverID, e.Path))
+			}
+		}
+		m.Entries = kept
+	})
+
+	return errors.Join(append(errs, err)...)
+}
@@ -0,0 +1,70 @@
+package mcpservetest
+
+import (
+	"encoding/json"
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// update rewrites golden files instead of comparing: go test ./... -args -mcpservetest.update
+var update = flag.Bool("mcpservetest.update", false, "rewrite mcpservetest golden files")
+
+// ToolsJSON renders the tools/list result as indented JSON, sorted by tool name
+func (c *Client) ToolsJSON() []byte {
+	c.t.Helper()
+	tools := c.ListTools()
+	sort.Slice(tools, func(i, j int) bool { return tools[i].Name < tools[j].Name })
+
+	data, err := json.MarshalIndent(map[string]any{"tools": tools}, "", "  ")
+	if err != nil {
+		c.t.Fatalf("mcpservetest: %v", err)
+	}
+	return append(data, '\n')
+}
+
+// AssertToolsGolden compares ToolsJSON with the golden file at path (usually under testdata/),
+// so schema changes show up in code review. Run the tests with -mcpservetest.update to
+// write the file.
+func (c *Client) AssertToolsGolden(path string) {
+	c.t.Helper()
+	got := c.ToolsJSON()
+
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			c.t.Fatalf("mcpservetest: %v", err)
+		}
+		if err := os.WriteFile(path, got, 0644); err != nil {
+			c.t.Fatalf("mcpservetest: %v", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		c.t.Fatalf("mcpservetest: golden file %s missing, run the tests with -args -mcpservetest.update", path)
+	}
+	if err != nil {
+		c.t.Fatalf("mcpservetest: %v", err)
+	}
+	if string(got) == string(want) {
+		return
+	}
+
+	// Point at the first difference; the full picture is in the diff of the updated file
+	gotLines, wantLines := strings.Split(string(got), "\n"), strings.Split(string(want), "\n")
+	line := 0
+	for line < len(gotLines) && line < len(wantLines) && gotLines[line] == wantLines[line] {
+		line++
+	}
+	at := func(lines []string) string {
+		if line < len(lines) {
+			return lines[line]
+		}
+		return "<end of file>"
+	}
+	c.t.Errorf("mcpservetest: tools/list differs from %s at line %d:\n  want: %s\n  got:  %s\nrun the tests with -args -mcpservetest.update to accept the change",
+		path, line+1, at(wantLines), at(gotLines))
+}
@@ -0,0 +1,113 @@
+// Package mcpservetest runs mcpserve handlers in-process for tests: tools go through the real
+// schema generation, argument handling, log capture and result formatting, without ports or sleeps.
+//
+//	c := mcpservetest.New(t, mcpserve.Config{}, myHandler)
+//	result := c.CallTool("build", map[string]any{"target": "wasm"})
+//	if result.IsError { ... }
+package mcpservetest
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/tinywasm/mcpserve"
+)
+
+// Client is an MCP client connected in-process to a Handler
+type Client struct {
+	Handler *mcpserve.Handler
+
+	t      testing.TB
+	client *client.Client
+}
+
+// New creates a Handler with the given tool handlers and an initialized in-process client.
+// Invalid tool metadata fails the test. The client is closed when the test ends.
+func New(t testing.TB, config mcpserve.Config, handlers ...any) *Client {
+	t.Helper()
+	return NewWithTUI(t, config, nil, handlers...)
+}
+
+// NewWithTUI is New with a TUI receiving refreshes and activity events
+func NewWithTUI(t testing.TB, config mcpserve.Config, tui mcpserve.TuiInterface, handlers ...any) *Client {
+	t.Helper()
+	handler := mcpserve.NewHandler(config, handlers, tui)
+	handler.SetLog(func(messages ...any) { t.Log(messages...) })
+	for _, problem := range handler.ValidateTools() {
+		t.Errorf("mcpservetest: %s", problem)
+	}
+
+	c, err := client.NewInProcessClient(handler.MCPServer())
+	if err != nil {
+		t.Fatalf("mcpservetest: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("mcpservetest: start: %v", err)
+	}
+	init := mcp.InitializeRequest{}
+	init.Params.ProtocolVersion = mcp.LATEST_PROTOCOL_VERSION
+	init.Params.ClientInfo = mcp.Implementation{Name: "mcpservetest", Version: "1.0.0"}
+	if _, err := c.Initialize(ctx, init); err != nil {
+		t.Fatalf("mcpservetest: initialize: %v", err)
+	}
+
+	return &Client{Handler: handler, t: t, client: c}
+}
+
+// ListTools returns the tools as advertised by tools/list
+func (c *Client) ListTools() []mcp.Tool {
+	c.t.Helper()
+	result, err := c.client.ListTools(context.Background(), mcp.ListToolsRequest{})
+	if err != nil {
+		c.t.Fatalf("mcpservetest: tools/list: %v", err)
+	}
+	return result.Tools
+}
+
+// Tool returns the advertised tool called name, failing the test when there is none
+func (c *Client) Tool(name string) mcp.Tool {
+	c.t.Helper()
+	for _, tool := range c.ListTools() {
+		if tool.Name == name {
+			return tool
+		}
+	}
+	c.t.Fatalf("mcpservetest: tool %q not found", name)
+	return mcp.Tool{}
+}
+
+// CallTool calls a tool through tools/call and returns its result.
+// Tool errors are reported in the result (IsError); protocol errors fail the test.
+func (c *Client) CallTool(name string, args map[string]any) *mcp.CallToolResult {
+	c.t.Helper()
+	req := mcp.CallToolRequest{}
+	req.Params.Name = name
+	req.Params.Arguments = args
+	result, err := c.client.CallTool(context.Background(), req)
+	if err != nil {
+		c.t.Fatalf("mcpservetest: tools/call %s: %v", name, err)
+	}
+	return result
+}
+
+// MCPClient returns the underlying mcp-go client, for requests not wrapped here
+func (c *Client) MCPClient() *client.Client {
+	return c.client
+}
+
+// Text returns the text content of a result, one line per text item
+func Text(result *mcp.CallToolResult) string {
+	lines := []string{}
+	for _, content := range result.Content {
+		if text, ok := content.(mcp.TextContent); ok {
+			lines = append(lines, text.Text)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
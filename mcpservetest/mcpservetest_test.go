@@ -0,0 +1,56 @@
+package mcpservetest
+
+import (
+	"testing"
+
+	"github.com/tinywasm/mcpserve"
+)
+
+// greeter is a handler declared the way domain packages do
+type greeter struct {
+	log func(message ...any)
+}
+
+func (g *greeter) Name() string                  { return "greeter" }
+func (g *greeter) SetLog(f func(message ...any)) { g.log = f }
+func (g *greeter) GetMCPToolsMetadata() []mcpserve.ToolMetadata {
+	return []mcpserve.ToolMetadata{{
+		Name:        "greet",
+		Description: "Greets someone",
+		Parameters: []mcpserve.ParameterMetadata{
+			{Name: "name", Type: "string", Required: true, Description: "Who to greet"},
+		},
+		Execute: func(args map[string]any) {
+			g.log("hello " + args["name"].(string))
+		},
+	}}
+}
+
+func TestClient(t *testing.T) {
+	c := New(t, mcpserve.Config{ServerName: "test", ServerVersion: "1.0.0"}, &greeter{})
+
+	tools := c.ListTools()
+	if len(tools) != 1 || tools[0].Name != "greet" {
+		t.Fatalf("Unexpected tools %+v", tools)
+	}
+	if required := c.Tool("greet").InputSchema.Required; len(required) != 1 || required[0] != "name" {
+		t.Errorf("Unexpected required parameters %v", required)
+	}
+
+	result := c.CallTool("greet", map[string]any{"name": "ana"})
+	if result.IsError || Text(result) != "hello ana" {
+		t.Errorf("Unexpected result %+v", result)
+	}
+
+	// Executor panics come back as error results
+	c.Handler.SetLog(func(...any) {})
+	result = c.CallTool("greet", nil)
+	if !result.IsError {
+		t.Errorf("Expected an error result, got %+v", result)
+	}
+}
+
+func TestToolsGolden(t *testing.T) {
+	c := New(t, mcpserve.Config{}, &greeter{})
+	c.AssertToolsGolden("testdata/greeter_tools.json")
+}
@@ -0,0 +1,142 @@
+package mcpserve
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyBuckets are the upper bounds, in seconds, of the tool latency histogram.
+// Tools range from instant lookups to full builds, hence the wide spread.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60}
+
+// ToolMetrics holds the usage counters of one tool, as returned by Handler.Metrics
+type ToolMetrics struct {
+	Tool   string
+	Calls  uint64 // Every call, including rejected ones
+	Errors uint64 // Calls whose result had isError set (panics included)
+	Panics uint64 // Executors that panicked
+
+	LatencyBuckets []float64     // Histogram upper bounds in seconds
+	LatencyCounts  []uint64      // Cumulative count of calls <= each bound
+	LatencySum     time.Duration // Total time spent in calls
+}
+
+// AverageLatency returns the mean call duration, or 0 without calls
+func (m ToolMetrics) AverageLatency() time.Duration {
+	if m.Calls == 0 {
+		return 0
+	}
+	return m.LatencySum / time.Duration(m.Calls)
+}
+
+// toolMetrics records per-tool counters and latencies
+type toolMetrics struct {
+	mu    sync.Mutex
+	tools map[string]*ToolMetrics
+}
+
+func newToolMetrics() *toolMetrics {
+	return &toolMetrics{tools: map[string]*ToolMetrics{}}
+}
+
+// record adds one call of tool
+func (m *toolMetrics) record(tool string, elapsed time.Duration, isError, panicked bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	t, ok := m.tools[tool]
+	if !ok {
+		t = &ToolMetrics{
+			Tool:           tool,
+			LatencyBuckets: latencyBuckets,
+			LatencyCounts:  make([]uint64, len(latencyBuckets)),
+		}
+		m.tools[tool] = t
+	}
+
+	t.Calls++
+	if isError {
+		t.Errors++
+	}
+	if panicked {
+		t.Panics++
+	}
+	t.LatencySum += elapsed
+	seconds := elapsed.Seconds()
+	for i, bound := range latencyBuckets {
+		if seconds <= bound {
+			t.LatencyCounts[i]++
+		}
+	}
+}
+
+// snapshot returns a copy of all tool metrics sorted by tool name
+func (m *toolMetrics) snapshot() []ToolMetrics {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	out := make([]ToolMetrics, 0, len(m.tools))
+	for _, t := range m.tools {
+		c := *t
+		c.LatencyCounts = append([]uint64(nil), t.LatencyCounts...)
+		out = append(out, c)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Tool < out[j].Tool })
+	return out
+}
+
+// Metrics returns the usage counters and latency histogram of every tool called so far
+func (h *Handler) Metrics() []ToolMetrics {
+	return h.metrics.snapshot()
+}
+
+// writePrometheus writes the metrics in the Prometheus text exposition format
+func writePrometheus(b *strings.Builder, metrics []ToolMetrics) {
+	counters := []struct {
+		name, help string
+		value      func(ToolMetrics) uint64
+	}{
+		{"mcpserve_tool_calls_total", "Tool calls received.", func(m ToolMetrics) uint64 { return m.Calls }},
+		{"mcpserve_tool_errors_total", "Tool calls that returned an error result.", func(m ToolMetrics) uint64 { return m.Errors }},
+		{"mcpserve_tool_panics_total", "Tool executions that panicked.", func(m ToolMetrics) uint64 { return m.Panics }},
+	}
+	for _, c := range counters {
+		fmt.Fprintf(b, "# HELP %s %s\n# TYPE %s counter\n", c.name, c.help, c.name)
+		for _, m := range metrics {
+			fmt.Fprintf(b, "%s{tool=\"%s\"} %d\n", c.name, promLabel(m.Tool), c.value(m))
+		}
+	}
+
+	const hist = "mcpserve_tool_duration_seconds"
+	fmt.Fprintf(b, "# HELP %s Tool call latency.\n# TYPE %s histogram\n", hist, hist)
+	for _, m := range metrics {
+		tool := promLabel(m.Tool)
+		for i, bound := range m.LatencyBuckets {
+			le := strconv.FormatFloat(bound, 'g', -1, 64)
+			fmt.Fprintf(b, "%s_bucket{tool=\"%s\",le=\"%s\"} %d\n", hist, tool, le, m.LatencyCounts[i])
+		}
+		fmt.Fprintf(b, "%s_bucket{tool=\"%s\",le=\"+Inf\"} %d\n", hist, tool, m.Calls)
+		fmt.Fprintf(b, "%s_sum{tool=\"%s\"} %s\n", hist, tool, strconv.FormatFloat(m.LatencySum.Seconds(), 'g', -1, 64))
+		fmt.Fprintf(b, "%s_count{tool=\"%s\"} %d\n", hist, tool, m.Calls)
+	}
+}
+
+// promLabel escapes a Prometheus label value
+func promLabel(value string) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(value)
+}
+
+// metricsHandler serves Handler.Metrics for Prometheus scrapers
+func (h *Handler) metricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b strings.Builder
+		writePrometheus(&b, h.Metrics())
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		w.Write([]byte(b.String()))
+	})
+}
@@ -0,0 +1,101 @@
+package mcpserve
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestToolMetricsRecord verifies counters and histogram buckets
+func TestToolMetricsRecord(t *testing.T) {
+	m := newToolMetrics()
+	m.record("build", 20*time.Millisecond, false, false)
+	m.record("build", 3*time.Second, true, true)
+	m.record("browser", time.Millisecond, true, false)
+
+	metrics := m.snapshot()
+	if len(metrics) != 2 || metrics[0].Tool != "browser" || metrics[1].Tool != "build" {
+		t.Fatalf("Expected metrics sorted by tool, got %+v", metrics)
+	}
+	build := metrics[1]
+	if build.Calls != 2 || build.Errors != 1 || build.Panics != 1 {
+		t.Errorf("Unexpected counters: %+v", build)
+	}
+	if build.AverageLatency() != 1510*time.Millisecond {
+		t.Errorf("Unexpected average latency %v", build.AverageLatency())
+	}
+
+	// 20ms falls in the 0.025 bucket, 3s in the 5 bucket
+	for i, bound := range build.LatencyBuckets {
+		want := uint64(0)
+		if bound >= 0.025 {
+			want = 1
+		}
+		if bound >= 5 {
+			want = 2
+		}
+		if build.LatencyCounts[i] != want {
+			t.Errorf("Bucket le=%v: expected %d, got %d", bound, want, build.LatencyCounts[i])
+		}
+	}
+
+	var b strings.Builder
+	writePrometheus(&b, metrics)
+	for _, line := range []string{
+		"# TYPE mcpserve_tool_calls_total counter",
+		`mcpserve_tool_calls_total{tool="build"} 2`,
+		`mcpserve_tool_panics_total{tool="build"} 1`,
+		`mcpserve_tool_duration_seconds_bucket{tool="build",le="0.025"} 1`,
+		`mcpserve_tool_duration_seconds_bucket{tool="build",le="+Inf"} 2`,
+		`mcpserve_tool_duration_seconds_sum{tool="build"} 3.02`,
+		`mcpserve_tool_duration_seconds_count{tool="browser"} 1`,
+	} {
+		if !strings.Contains(b.String(), line+"\n") {
+			t.Errorf("Missing %q in:\n%s", line, b.String())
+		}
+	}
+}
+
+// TestExecuteToolPanic verifies panics become error results and are counted
+func TestExecuteToolPanic(t *testing.T) {
+	handler := NewHandler(Config{}, nil, &mockTUI{})
+	execute := handler.mcpExecuteTool(&mockHandler{}, func(map[string]any) { panic("boom") }, nil)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "test_tool"
+	result, err := execute(context.Background(), req)
+	if err != nil || !result.IsError {
+		t.Fatalf("Expected an error result, got %+v, %v", result, err)
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; text != "tool test_tool panicked: boom" {
+		t.Errorf("Unexpected result text %q", text)
+	}
+
+	metrics := handler.Metrics()
+	if len(metrics) != 1 || metrics[0].Calls != 1 || metrics[0].Errors != 1 || metrics[0].Panics != 1 {
+		t.Errorf("Unexpected metrics %+v", metrics)
+	}
+}
+
+// TestMetricsEndpoint verifies the optional /metrics path
+func TestMetricsEndpoint(t *testing.T) {
+	handler := NewHandler(Config{Port: "0", MetricsPath: "/metrics"}, nil, &mockTUI{})
+	startTestHandler(t, handler)
+	defer handler.Shutdown(context.Background())
+	handler.metrics.record("build", time.Second, false, false)
+
+	resp, err := http.Get("http://localhost:" + handler.Port() + "/metrics")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `mcpserve_tool_calls_total{tool="build"} 1`) {
+		t.Errorf("Unexpected metrics body:\n%s", body)
+	}
+}
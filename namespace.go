@@ -0,0 +1,75 @@
+package mcpserve
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"strings"
+)
+
+const (
+	defaultNamespaceSeparator = "_"
+	maxToolNameLength         = 128
+)
+
+// validToolName are the characters MCP allows in tool names
+var validToolName = regexp.MustCompile(`^[A-Za-z0-9_.-]+$`)
+
+// checkToolName reports why name is not a valid MCP tool name, or ""
+func checkToolName(name string) string {
+	switch {
+	case len(name) > maxToolNameLength:
+		return fmt.Sprintf("name longer than %d characters", maxToolNameLength)
+	case !validToolName.MatchString(name):
+		return "name may only contain letters, digits, '_', '-' and '.'"
+	}
+	return ""
+}
+
+// namespaceSeparator returns Config.ToolNamespaceSeparator or its default, and a problem when it is invalid
+func (h *Handler) namespaceSeparator() (string, string) {
+	sep := h.config.ToolNamespaceSeparator
+	if sep == "" {
+		return defaultNamespaceSeparator, ""
+	}
+	if !validToolName.MatchString(sep) {
+		return defaultNamespaceSeparator, fmt.Sprintf("ToolNamespaceSeparator %q: may only contain letters, digits, '_', '-' and '.', using %q", sep, defaultNamespaceSeparator)
+	}
+	return sep, ""
+}
+
+// toolPrefix returns the namespace of a handler's tools: its MCPToolPrefix() method when it has one,
+// else its Name() when Config.ToolNamespaces is set, else ""
+func (h *Handler) toolPrefix(handler any) string {
+	if method := reflect.ValueOf(handler).MethodByName("MCPToolPrefix"); method.IsValid() {
+		if method.Type().NumIn() == 0 && method.Type().NumOut() == 1 && method.Type().Out(0).Kind() == reflect.String {
+			return method.Call(nil)[0].String()
+		}
+	}
+	if h.config.ToolNamespaces {
+		return namespaceFromName(handlerName(handler))
+	}
+	return ""
+}
+
+// namespaceFromName turns a handler name such as "Dev Browser" into "dev_browser"
+func namespaceFromName(name string) string {
+	var b strings.Builder
+	for _, r := range strings.ToLower(strings.TrimSpace(name)) {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '_', r == '-', r == '.':
+			b.WriteRune(r)
+		default:
+			b.WriteRune('_')
+		}
+	}
+	return b.String()
+}
+
+// namespaced prefixes name unless it already carries the prefix
+func namespaced(prefix, sep, name string) string {
+	if prefix == "" || name == "" || strings.HasPrefix(name, prefix+sep) {
+		return name
+	}
+	return prefix + sep + name
+}
@@ -0,0 +1,83 @@
+package mcpserve
+
+import (
+	"sort"
+	"strings"
+	"testing"
+)
+
+// statusHandler exposes a "status" tool under the given handler name
+type statusHandler struct{ name string }
+
+func (s *statusHandler) Name() string                  { return s.name }
+func (s *statusHandler) SetLog(f func(message ...any)) {}
+func (s *statusHandler) GetMCPToolsMetadata() []ToolMetadata {
+	noop := func(map[string]any) {}
+	return []ToolMetadata{
+		{Name: "status", Execute: noop},
+		{Name: strings.ToLower(s.name) + "_reload", Execute: noop}, // Already prefixed
+	}
+}
+
+// prefixedHandler chooses its own prefix
+type prefixedHandler struct{ statusHandler }
+
+func (p *prefixedHandler) MCPToolPrefix() string { return "wasm" }
+
+func registeredTools(h *Handler) []string {
+	h.MCPServer()
+	names := []string{}
+	for name := range h.tools {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestToolNamespaces(t *testing.T) {
+	handlers := []any{&statusHandler{name: "Browser"}, &statusHandler{name: "Client"}, &prefixedHandler{statusHandler{name: "Builder"}}}
+
+	// Without namespaces the second status collides and is skipped
+	handler := NewHandler(Config{}, handlers, nil)
+	problems := handler.ValidateTools()
+	if len(problems) != 1 || !strings.Contains(problems[0].String(), `tool status: duplicate tool name`) {
+		t.Errorf("Expected one duplicate, got %v", problems)
+	}
+
+	handler = NewHandler(Config{ToolNamespaces: true}, handlers, nil)
+	want := "browser_reload,browser_status,client_reload,client_status,wasm_builder_reload,wasm_status"
+	if got := strings.Join(registeredTools(handler), ","); got != want {
+		t.Errorf("Expected %s, got %s", want, got)
+	}
+
+	handler = NewHandler(Config{ToolNamespaces: true, ToolNamespaceSeparator: "."}, handlers[:1], nil)
+	if got := strings.Join(registeredTools(handler), ","); got != "browser.browser_reload,browser.status" {
+		t.Errorf("Unexpected names with '.' separator: %s", got)
+	}
+}
+
+func TestToolNamespaceSeparatorInvalid(t *testing.T) {
+	handler := NewHandler(Config{ToolNamespaces: true, ToolNamespaceSeparator: "/"}, []any{&statusHandler{name: "Browser"}}, nil)
+
+	problems := handler.ValidateTools()
+	if len(problems) != 1 || problems[0].Handler != "Config" {
+		t.Fatalf("Expected a separator problem, got %v", problems)
+	}
+	if got := strings.Join(registeredTools(handler), ","); got != "browser_reload,browser_status" {
+		t.Errorf("Expected the default separator as fallback, got %s", got)
+	}
+}
+
+func TestCheckToolName(t *testing.T) {
+	for name, valid := range map[string]bool{
+		"browser_status":         true,
+		"v1.build-all":           true,
+		"has space":              false,
+		"slash/name":             false,
+		strings.Repeat("a", 129): false,
+	} {
+		if got := checkToolName(name) == ""; got != valid {
+			t.Errorf("checkToolName(%q): expected valid=%v", name, valid)
+		}
+	}
+}
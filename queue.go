@@ -0,0 +1,94 @@
+package mcpserve
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// defaultMaxQueueDepth is used when Config.MaxQueueDepth is not set
+const defaultMaxQueueDepth = 16
+
+// handlerQueue serializes executions targeting one handler in FIFO order
+type handlerQueue struct {
+	mu       sync.Mutex
+	busy     bool
+	waiters  []chan struct{}
+	maxDepth int
+}
+
+// newHandlerQueue creates a queue holding at most maxDepth waiting calls
+func newHandlerQueue(maxDepth int) *handlerQueue {
+	if maxDepth <= 0 {
+		maxDepth = defaultMaxQueueDepth
+	}
+	return &handlerQueue{maxDepth: maxDepth}
+}
+
+// enter blocks until the caller owns the handler, the queue is full, or ctx is done.
+// queued reports whether the caller had to wait behind another execution.
+// On success, release must be called once the execution finishes.
+func (q *handlerQueue) enter(ctx context.Context) (release func(), queued bool, err error) {
+	q.mu.Lock()
+	if !q.busy {
+		q.busy = true
+		q.mu.Unlock()
+		return q.release, false, nil
+	}
+	if len(q.waiters) >= q.maxDepth {
+		q.mu.Unlock()
+		return nil, false, fmt.Errorf("execution queue full (%d calls waiting), retry later", q.maxDepth)
+	}
+	turn := make(chan struct{})
+	q.waiters = append(q.waiters, turn)
+	q.mu.Unlock()
+
+	select {
+	case <-turn:
+		return q.release, true, nil
+	case <-ctx.Done():
+		q.mu.Lock()
+		for i, w := range q.waiters {
+			if w == turn {
+				q.waiters = append(q.waiters[:i], q.waiters[i+1:]...)
+				q.mu.Unlock()
+				return nil, true, ctx.Err()
+			}
+		}
+		q.mu.Unlock()
+		// The turn was handed over while cancelling: pass it on
+		q.release()
+		return nil, true, ctx.Err()
+	}
+}
+
+// release hands the handler to the next waiter, or marks it idle
+func (q *handlerQueue) release() {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	if len(q.waiters) == 0 {
+		q.busy = false
+		return
+	}
+	next := q.waiters[0]
+	q.waiters = q.waiters[1:]
+	close(next)
+}
+
+// wantsSerialExecution reports whether a handler opted into serialized execution
+// Looks for a method called "SerializeMCPExecution() bool"
+func wantsSerialExecution(handler any) bool {
+	method := reflect.ValueOf(handler).MethodByName("SerializeMCPExecution")
+	if !method.IsValid() || method.Type().NumIn() != 0 || method.Type().NumOut() != 1 {
+		return false
+	}
+	result := method.Call(nil)[0]
+	return result.Kind() == reflect.Bool && result.Bool()
+}
+
+// queueWaitNote formats the queue wait reported back to the client
+func queueWaitNote(wait time.Duration) string {
+	return fmt.Sprintf("(queued %d ms behind earlier calls to this handler)", wait.Milliseconds())
+}
@@ -0,0 +1,200 @@
+package mcpserve
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// serialHandler opts into serialized execution via the marker method
+type serialHandler struct {
+	mockHandler
+}
+
+func (s *serialHandler) SerializeMCPExecution() bool { return true }
+
+// TestHandlerQueueFIFO verifies waiters run one at a time in arrival order
+func TestHandlerQueueFIFO(t *testing.T) {
+	queue := newHandlerQueue(4)
+
+	release, queued, err := queue.enter(context.Background())
+	if err != nil || queued {
+		t.Fatalf("First caller should own the queue immediately (queued=%v, err=%v)", queued, err)
+	}
+
+	order := make(chan int, 3)
+	for i := 0; i < 3; i++ {
+		go func(n int) {
+			leave, _, err := queue.enter(context.Background())
+			if err != nil {
+				t.Errorf("waiter %d: %v", n, err)
+				return
+			}
+			order <- n
+			leave()
+		}(i)
+		// Let each waiter enqueue before starting the next one
+		waitForWaiters(t, queue, i+1)
+	}
+
+	release()
+	for want := 0; want < 3; want++ {
+		if got := <-order; got != want {
+			t.Fatalf("Expected waiter %d to run, got %d", want, got)
+		}
+	}
+}
+
+// TestHandlerQueueDepthAndCancel verifies the depth limit and removal of cancelled waiters
+func TestHandlerQueueDepthAndCancel(t *testing.T) {
+	queue := newHandlerQueue(1)
+	release, _, _ := queue.enter(context.Background())
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, _, err := queue.enter(ctx)
+		done <- err
+	}()
+	waitForWaiters(t, queue, 1)
+
+	if _, _, err := queue.enter(context.Background()); err == nil {
+		t.Error("Expected queue full error")
+	}
+
+	cancel()
+	if err := <-done; err == nil {
+		t.Error("Expected cancelled waiter to return an error")
+	}
+
+	release()
+	if _, queued, err := queue.enter(context.Background()); err != nil || queued {
+		t.Errorf("Queue should be idle after cancel and release (queued=%v, err=%v)", queued, err)
+	}
+}
+
+// TestWantsSerialExecution verifies the reflected marker method
+func TestWantsSerialExecution(t *testing.T) {
+	if wantsSerialExecution(&mockHandler{}) {
+		t.Error("mockHandler should not be serialized")
+	}
+	if !wantsSerialExecution(&serialHandler{}) {
+		t.Error("serialHandler should be serialized")
+	}
+}
+
+// deployHandler has one serialized tool that blocks until released, and one unflagged tool
+type deployHandler struct {
+	log      func(message ...any)
+	started  chan struct{}
+	finished chan struct{}
+}
+
+func (d *deployHandler) Name() string                  { return "deploy" }
+func (d *deployHandler) SetLog(f func(message ...any)) { d.log = f }
+
+func (d *deployHandler) GetMCPToolsMetadata() []ToolMetadata {
+	return []ToolMetadata{
+		{Name: "deploy", Description: "Deploys", Serialized: true, Execute: func(map[string]any) {
+			close(d.started)
+			<-d.finished
+			d.log("deployed")
+		}},
+		{Name: "status", Description: "Reports status", Execute: func(map[string]any) {
+			d.log("idle")
+		}},
+	}
+}
+
+// TestSerializedToolQueuesHandler verifies one Serialized tool queues the other tools of its handler
+func TestSerializedToolQueuesHandler(t *testing.T) {
+	deploy := &deployHandler{started: make(chan struct{}), finished: make(chan struct{})}
+	s := NewHandler(Config{}, []any{deploy}, &mockTUI{}).MCPServer()
+
+	call := func(name string) string {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = name
+		result, err := s.GetTool(name).Handler(context.Background(), req)
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			return ""
+		}
+		return result.Content[0].(mcp.TextContent).Text
+	}
+
+	deployed := make(chan string, 1)
+	go func() { deployed <- call("deploy") }()
+	<-deploy.started
+
+	status := make(chan string, 1)
+	go func() { status <- call("status") }()
+	select {
+	case text := <-status:
+		t.Fatalf("status ran while deploy was running: %q", text)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(deploy.finished)
+	if text := <-deployed; text != "deployed" {
+		t.Errorf("deploy captured %q", text)
+	}
+	if text := <-status; !strings.HasPrefix(text, "idle\n(queued ") {
+		t.Errorf("Expected status to report its queue wait, got %q", text)
+	}
+}
+
+// screenshotHandler returns an image through its logger
+type screenshotHandler struct {
+	log func(message ...any)
+}
+
+func (s *screenshotHandler) Name() string                  { return "browser" }
+func (s *screenshotHandler) SetLog(f func(message ...any)) { s.log = f }
+
+// TestQueueWaitNoteBinary verifies image results of queued calls also report the wait
+func TestQueueWaitNoteBinary(t *testing.T) {
+	handler := NewHandler(Config{}, nil, &mockTUI{})
+	shot := &screenshotHandler{}
+	queue := newHandlerQueue(4)
+	execute := handler.mcpExecuteTool(shot, func(map[string]any) {
+		shot.log("Screenshot taken", BinaryData{MimeType: "image/png", Data: []byte{1, 2, 3}})
+	}, queue)
+
+	release, _, _ := queue.enter(context.Background())
+	done := make(chan *mcp.CallToolResult, 1)
+	go func() {
+		req := mcp.CallToolRequest{}
+		req.Params.Name = "browser_screenshot"
+		result, _ := execute(context.Background(), req)
+		done <- result
+	}()
+	waitForWaiters(t, queue, 1)
+	release()
+
+	result := <-done
+	text := result.Content[0].(mcp.TextContent).Text
+	if !strings.HasPrefix(text, "Screenshot taken\n(queued ") {
+		t.Errorf("Expected the queue wait in the image summary, got %q", text)
+	}
+	if _, ok := result.Content[1].(mcp.ImageContent); !ok {
+		t.Errorf("Expected image content, got %+v", result.Content)
+	}
+}
+
+func waitForWaiters(t *testing.T, queue *handlerQueue, n int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		queue.mu.Lock()
+		count := len(queue.waiters)
+		queue.mu.Unlock()
+		if count >= n {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("Timed out waiting for %d queued callers", n)
+}
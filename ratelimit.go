@@ -0,0 +1,161 @@
+package mcpserve
+
+import (
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// RateLimit configures token-bucket throttling and a concurrency cap for a tool
+type RateLimit struct {
+	Rate          float64 // Sustained calls per second (0 = no token bucket)
+	Burst         int     // Bucket size, calls allowed back-to-back (defaults to 1)
+	MaxConcurrent int     // Max executions running at the same time (0 = unlimited)
+	PerSession    bool    // Track limits per client session instead of per tool
+}
+
+// enabled reports whether the limit restricts anything at all
+func (l RateLimit) enabled() bool {
+	return l.Rate > 0 || l.MaxConcurrent > 0
+}
+
+// rateLimitError is returned when a call is rejected by the limiter
+type rateLimitError struct {
+	retryAfter time.Duration
+	running    int // Set when the concurrency cap was hit
+}
+
+func (e *rateLimitError) Error() string {
+	ms := e.retryAfter.Milliseconds()
+	if ms < 1 {
+		ms = 1
+	}
+	if e.running > 0 {
+		return fmt.Sprintf("rate limited, %d executions already running, retry after %d ms", e.running, ms)
+	}
+	return fmt.Sprintf("rate limited, retry after %d ms", ms)
+}
+
+// tokenBucket is a classic token bucket refilled lazily on each take
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+	rate   float64
+	burst  float64
+}
+
+// full reports whether the bucket would be at capacity at the given time
+func (b *tokenBucket) full(now time.Time) bool {
+	return b.tokens+now.Sub(b.last).Seconds()*b.rate >= b.burst
+}
+
+// maxLimiterKeys bounds the number of tracked buckets before idle ones are pruned
+const maxLimiterKeys = 1024
+
+// concurrencyRetryHint is suggested to clients rejected by MaxConcurrent alone
+const concurrencyRetryHint = 100 * time.Millisecond
+
+// rateLimiter enforces RateLimit settings for every tool call
+type rateLimiter struct {
+	mu      sync.Mutex
+	limits  map[string]RateLimit // Per-tool overrides
+	def     RateLimit            // Applied to tools without an override
+	buckets map[string]*tokenBucket
+	running map[string]int
+	now     func() time.Time
+}
+
+// newRateLimiter creates a limiter from the handler configuration
+func newRateLimiter(limits map[string]RateLimit, def RateLimit) *rateLimiter {
+	return &rateLimiter{
+		limits:  limits,
+		def:     def,
+		buckets: make(map[string]*tokenBucket),
+		running: make(map[string]int),
+		now:     time.Now,
+	}
+}
+
+// limitFor returns the limit that applies to a tool
+func (r *rateLimiter) limitFor(tool string) RateLimit {
+	if l, ok := r.limits[tool]; ok {
+		return l
+	}
+	return r.def
+}
+
+// acquire admits a call or rejects it with a *rateLimitError.
+// On success the returned release func must be called when the execution ends.
+func (r *rateLimiter) acquire(tool, session string) (release func(), err error) {
+	limit := r.limitFor(tool)
+	if !limit.enabled() {
+		return func() {}, nil
+	}
+
+	key := tool
+	if limit.PerSession && session != "" {
+		key = tool + "\x00" + session
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// 1. Concurrency cap (checked first so a rejected call never burns a token)
+	if limit.MaxConcurrent > 0 && r.running[key] >= limit.MaxConcurrent {
+		return nil, &rateLimitError{retryAfter: concurrencyRetryHint, running: r.running[key]}
+	}
+
+	// 2. Token bucket
+	if limit.Rate > 0 {
+		burst := float64(limit.Burst)
+		if burst < 1 {
+			burst = 1
+		}
+
+		now := r.now()
+		bucket, ok := r.buckets[key]
+		if !ok {
+			r.pruneBuckets(now)
+			bucket = &tokenBucket{tokens: burst, last: now, rate: limit.Rate, burst: burst}
+			r.buckets[key] = bucket
+		}
+
+		bucket.tokens = math.Min(burst, bucket.tokens+now.Sub(bucket.last).Seconds()*limit.Rate)
+		bucket.last = now
+
+		if bucket.tokens < 1 {
+			wait := time.Duration((1 - bucket.tokens) / limit.Rate * float64(time.Second))
+			return nil, &rateLimitError{retryAfter: wait}
+		}
+		bucket.tokens--
+	}
+
+	// 3. Take a concurrency slot
+	if limit.MaxConcurrent > 0 {
+		r.running[key]++
+		return func() {
+			r.mu.Lock()
+			defer r.mu.Unlock()
+			if r.running[key]--; r.running[key] <= 0 {
+				delete(r.running, key)
+			}
+		}, nil
+	}
+
+	return func() {}, nil
+}
+
+// pruneBuckets drops buckets that have refilled completely once the map grows too large.
+// A full bucket carries no state, so removing it does not change limiter behavior.
+// Must be called with r.mu held.
+func (r *rateLimiter) pruneBuckets(now time.Time) {
+	if len(r.buckets) < maxLimiterKeys {
+		return
+	}
+	for key, bucket := range r.buckets {
+		if bucket.full(now) {
+			delete(r.buckets, key)
+		}
+	}
+}
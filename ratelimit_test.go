@@ -0,0 +1,100 @@
+package mcpserve
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// TestRateLimiterTokenBucket verifies burst, rejection and refill of the token bucket
+func TestRateLimiterTokenBucket(t *testing.T) {
+	now := time.Unix(0, 0)
+	limiter := newRateLimiter(map[string]RateLimit{
+		"wasm_build": {Rate: 2, Burst: 2},
+	}, RateLimit{})
+	limiter.now = func() time.Time { return now }
+
+	for i := 0; i < 2; i++ {
+		if _, err := limiter.acquire("wasm_build", ""); err != nil {
+			t.Fatalf("call %d within burst rejected: %v", i, err)
+		}
+	}
+
+	_, err := limiter.acquire("wasm_build", "")
+	if err == nil {
+		t.Fatal("Expected third call to be rate limited")
+	}
+	if !strings.Contains(err.Error(), "retry after 500 ms") {
+		t.Errorf("Unexpected error message: %v", err)
+	}
+
+	// Tools without a limit are never throttled
+	for i := 0; i < 10; i++ {
+		if _, err := limiter.acquire("other_tool", ""); err != nil {
+			t.Fatalf("Unlimited tool rejected: %v", err)
+		}
+	}
+
+	now = now.Add(500 * time.Millisecond)
+	if _, err := limiter.acquire("wasm_build", ""); err != nil {
+		t.Errorf("Expected refilled token after 500ms: %v", err)
+	}
+}
+
+// TestRateLimiterConcurrency verifies MaxConcurrent and per-session isolation
+func TestRateLimiterConcurrency(t *testing.T) {
+	limiter := newRateLimiter(nil, RateLimit{MaxConcurrent: 1, PerSession: true})
+
+	release, err := limiter.acquire("wasm_build", "session-a")
+	if err != nil {
+		t.Fatalf("First call rejected: %v", err)
+	}
+
+	if _, err := limiter.acquire("wasm_build", "session-a"); err == nil {
+		t.Error("Expected second concurrent call in same session to be rejected")
+	}
+
+	if _, err := limiter.acquire("wasm_build", "session-b"); err != nil {
+		t.Errorf("Other session should have its own slot: %v", err)
+	}
+
+	release()
+	if _, err := limiter.acquire("wasm_build", "session-a"); err != nil {
+		t.Errorf("Slot should be free after release: %v", err)
+	}
+}
+
+// TestExecuteToolRateLimited verifies the executor returns an isError result when limited
+func TestExecuteToolRateLimited(t *testing.T) {
+	config := Config{
+		Port:       "3030",
+		RateLimits: map[string]RateLimit{"test_tool": {Rate: 1, Burst: 1}},
+	}
+	mock := &mockHandler{}
+	handler := NewHandler(config, []any{mock}, &mockTUI{})
+
+	tools, err := handler.mcpToolsFromHandler(mock)
+	if err != nil {
+		t.Fatalf("Failed to extract tools: %v", err)
+	}
+	execute := handler.mcpExecuteTool(mock, tools[0].Execute, nil)
+
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "test_tool"
+
+	result, _ := execute(context.Background(), req)
+	if result.IsError {
+		t.Fatalf("First call should succeed: %+v", result)
+	}
+
+	result, _ = execute(context.Background(), req)
+	if !result.IsError {
+		t.Fatal("Second call should be rate limited")
+	}
+	if text := result.Content[0].(mcp.TextContent).Text; !strings.HasPrefix(text, "rate limited, retry after") {
+		t.Errorf("Unexpected result text: %q", text)
+	}
+}
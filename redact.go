@@ -0,0 +1,79 @@
+package mcpserve
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// minSecretLength is the shortest sensitive argument value masked in output;
+// shorter values would mask unrelated text
+const minSecretLength = 4
+
+// DefaultSecretPatterns are always scrubbed from tool output; add more with Config.OutputScrubPatterns
+var DefaultSecretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`eyJ[A-Za-z0-9_-]{5,}\.eyJ[A-Za-z0-9_-]{5,}\.[A-Za-z0-9_-]{5,}`),              // JWT
+	regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`),                                              // AWS access key ID
+	regexp.MustCompile(`\b(?:gh[pousr]_[A-Za-z0-9]{36,}|github_pat_[A-Za-z0-9_]{22,})\b`),            // GitHub token
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),                                                    // OpenAI / Anthropic style key
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),                                             // Slack token
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}\b`),                                                  // Google API key
+	regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9._~+/-]{16,}=*`),                                    // Authorization header value
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?-----END [A-Z ]*PRIVATE KEY-----`), // PEM private key
+}
+
+// isSensitive reports whether argument name of tool was marked Sensitive
+func (h *Handler) isSensitive(tool, name string) bool {
+	meta, _ := h.tool(tool)
+	for _, param := range meta.Parameters {
+		if param.Name == name {
+			return param.Sensitive
+		}
+	}
+	return false
+}
+
+// redactArgs returns a copy of args with the values of sensitive arguments replaced
+func redactArgs(args map[string]any, sensitive func(name string) bool) map[string]any {
+	if len(args) == 0 {
+		return nil
+	}
+	out := make(map[string]any, len(args))
+	for name, value := range args {
+		if sensitive(name) {
+			value = redactedValue
+		}
+		out[name] = value
+	}
+	return out
+}
+
+// secretValues returns the values of the sensitive arguments of a call, longest first
+func (h *Handler) secretValues(tool string, args map[string]any) []string {
+	var secrets []string
+	for name, value := range args {
+		if !h.isSensitive(tool, name) {
+			continue
+		}
+		if s := fmt.Sprint(value); len(s) >= minSecretLength {
+			secrets = append(secrets, s)
+		}
+	}
+	// Longest first, so a secret containing another is masked whole
+	sort.Slice(secrets, func(i, j int) bool { return len(secrets[i]) > len(secrets[j]) })
+	return secrets
+}
+
+// scrub masks the given secret values and every secret pattern in text
+func (h *Handler) scrub(text string, secrets []string) string {
+	for _, secret := range secrets {
+		text = strings.ReplaceAll(text, secret, redactedValue)
+	}
+	for _, patterns := range [][]*regexp.Regexp{DefaultSecretPatterns, h.config.OutputScrubPatterns} {
+		for _, pattern := range patterns {
+			text = pattern.ReplaceAllLiteralString(text, redactedValue)
+		}
+	}
+	return text
+}
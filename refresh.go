@@ -0,0 +1,92 @@
+package mcpserve
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// defaultRefreshDebounce is used when Config.RefreshDebounce is zero
+const defaultRefreshDebounce = 50 * time.Millisecond
+
+// SectionRefresher is optionally implemented by the TUI to redraw only the section owned by
+// the handler whose tool ran. name is the handler's Name(); handlers without one trigger RefreshUI.
+type SectionRefresher interface {
+	RefreshSection(name string)
+}
+
+// refresher coalesces TUI refreshes: the first request schedules a refresh after the window
+// and every request until then joins it, so a burst of calls redraws once
+type refresher struct {
+	tui    TuiInterface
+	window time.Duration
+
+	mu       sync.Mutex
+	timer    *time.Timer
+	full     bool            // RefreshUI is pending
+	sections map[string]bool // RefreshSection calls pending
+}
+
+func newRefresher(tui TuiInterface, window time.Duration) *refresher {
+	if window == 0 {
+		window = defaultRefreshDebounce
+	}
+	return &refresher{tui: tui, window: window, sections: map[string]bool{}}
+}
+
+// request schedules a refresh of section, or of the whole UI when section is ""
+func (r *refresher) request(section string) {
+	if r.tui == nil {
+		return
+	}
+	_, targeted := r.tui.(SectionRefresher)
+
+	r.mu.Lock()
+	if section == "" || !targeted {
+		r.full = true
+	} else {
+		r.sections[section] = true
+	}
+	if r.window < 0 {
+		r.mu.Unlock()
+		r.flush()
+		return
+	}
+	if r.timer == nil {
+		r.timer = time.AfterFunc(r.window, r.flush)
+	}
+	r.mu.Unlock()
+}
+
+// flush runs the pending refreshes now
+func (r *refresher) flush() {
+	r.mu.Lock()
+	if r.timer != nil {
+		r.timer.Stop()
+		r.timer = nil
+	}
+	full, sections := r.full, r.sections
+	r.full, r.sections = false, map[string]bool{}
+	r.mu.Unlock()
+
+	if full {
+		r.tui.RefreshUI() // Covers every section
+		return
+	}
+	names := make([]string, 0, len(sections))
+	for name := range sections {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		r.tui.(SectionRefresher).RefreshSection(name)
+	}
+}
+
+// handlerName returns the Name() of a Loggable handler, or ""
+func handlerName(handler any) string {
+	if loggable, ok := handler.(Loggable); ok {
+		return loggable.Name()
+	}
+	return ""
+}
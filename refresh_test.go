@@ -0,0 +1,110 @@
+package mcpserve
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// countingTUI counts full and per-section refreshes
+type countingTUI struct {
+	mu       sync.Mutex
+	full     int
+	sections []string
+}
+
+func (c *countingTUI) RefreshUI() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.full++
+}
+
+func (c *countingTUI) counts() (int, string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.full, strings.Join(c.sections, ",")
+}
+
+// sectionTUI also supports targeted refreshes
+type sectionTUI struct {
+	countingTUI
+}
+
+func (s *sectionTUI) RefreshSection(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.sections = append(s.sections, name)
+}
+
+// namedHandler is a Loggable handler with a read-only and a mutating tool
+type namedHandler struct{}
+
+func (n *namedHandler) Name() string                  { return "browser" }
+func (n *namedHandler) SetLog(f func(message ...any)) {}
+func (n *namedHandler) GetMCPToolsMetadata() []ToolMetadata {
+	return []ToolMetadata{
+		{Name: "browser_status", ReadOnly: true, Execute: func(map[string]any) {}},
+		{Name: "browser_reload", Execute: func(map[string]any) {}},
+	}
+}
+
+// callTool registers the handler tools and calls one of them
+func callTool(t *testing.T, h *Handler, handler any, name string) {
+	t.Helper()
+	for _, tool := range h.tools {
+		if tool.Name == name {
+			req := mcp.CallToolRequest{}
+			req.Params.Name = name
+			h.mcpExecuteTool(handler, tool.Execute, nil)(context.Background(), req)
+			return
+		}
+	}
+	t.Fatalf("Tool %s not registered", name)
+}
+
+// TestRefreshDebounce verifies bursts of calls are coalesced into one refresh
+func TestRefreshDebounce(t *testing.T) {
+	tui := &countingTUI{}
+	handler := NewHandler(Config{RefreshDebounce: 30 * time.Millisecond}, []any{&namedHandler{}}, tui)
+	handler.MCPServer()
+
+	for i := 0; i < 10; i++ {
+		callTool(t, handler, &namedHandler{}, "browser_reload")
+	}
+	if full, _ := tui.counts(); full != 0 {
+		t.Errorf("Refresh should wait for the debounce window, got %d", full)
+	}
+	time.Sleep(100 * time.Millisecond)
+	if full, _ := tui.counts(); full != 1 {
+		t.Errorf("Expected 1 coalesced refresh, got %d", full)
+	}
+
+	// Read-only tools never refresh
+	callTool(t, handler, &namedHandler{}, "browser_status")
+	time.Sleep(100 * time.Millisecond)
+	if full, _ := tui.counts(); full != 1 {
+		t.Errorf("Read-only tool triggered a refresh")
+	}
+}
+
+// TestRefreshSection verifies targeted refreshes and the immediate mode
+func TestRefreshSection(t *testing.T) {
+	tui := &sectionTUI{}
+	handler := NewHandler(Config{RefreshDebounce: -1}, []any{&namedHandler{}, &mockHandler{}}, tui)
+	handler.MCPServer()
+
+	callTool(t, handler, &namedHandler{}, "browser_reload")
+	if full, sections := tui.counts(); full != 0 || sections != "browser" {
+		t.Errorf("Expected a browser section refresh, got full=%d sections=%q", full, sections)
+	}
+
+	// Handlers without Name() refresh the whole UI
+	callTool(t, handler, &mockHandler{}, "test_tool")
+	if full, _ := tui.counts(); full != 1 {
+		t.Errorf("Expected a full refresh, got %d", full)
+	}
+}
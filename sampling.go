@@ -0,0 +1,102 @@
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+	"github.com/mark3labs/mcp-go/server"
+)
+
+// defaultSamplingTimeout bounds one sampling request; the client may ask the user for approval
+const defaultSamplingTimeout = 60 * time.Second
+
+// ErrSamplingUnsupported is returned by the sampling function when the calling client did not
+// declare the sampling capability; handlers should fall back to their non-LLM behaviour
+var ErrSamplingUnsupported = errors.New("MCP client does not support sampling")
+
+// SamplingFunc asks the LLM of the client that called the tool to complete prompt, e.g. to
+// summarize a build log. maxTokens <= 0 uses a default of 1024.
+// Handlers receive it before each call through an optional method (with a locally declared func type);
+// their calls are then serialized so the sampler always belongs to the running call:
+//
+//	func (h *MyHandler) SetMCPSampler(sample func(systemPrompt, prompt string, maxTokens int) (string, error))
+type SamplingFunc func(systemPrompt, prompt string, maxTokens int) (string, error)
+
+// samplerSetter returns the SetMCPSampler method of a handler, if it has one with a compatible signature
+func samplerSetter(handler any) (reflect.Value, bool) {
+	method := reflect.ValueOf(handler).MethodByName("SetMCPSampler")
+	if !method.IsValid() || method.Type().NumIn() != 1 {
+		return reflect.Value{}, false
+	}
+	param := method.Type().In(0)
+	return method, reflect.TypeOf(SamplingFunc(nil)).ConvertibleTo(param)
+}
+
+// wantsSampling reports whether any handler can use sampling
+func (h *Handler) wantsSampling() bool {
+	for _, handler := range h.toolHandlers {
+		if _, ok := samplerSetter(handler); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// injectSampler gives the handler a sampling function bound to the session of the current call
+func (h *Handler) injectSampler(handler any, ctx context.Context) {
+	method, ok := samplerSetter(handler)
+	if !ok {
+		return
+	}
+	sample := SamplingFunc(func(systemPrompt, prompt string, maxTokens int) (string, error) {
+		return h.sample(ctx, systemPrompt, prompt, maxTokens)
+	})
+	method.Call([]reflect.Value{reflect.ValueOf(sample).Convert(method.Type().In(0))})
+}
+
+// sample sends sampling/createMessage to the client session in ctx
+func (h *Handler) sample(ctx context.Context, systemPrompt, prompt string, maxTokens int) (string, error) {
+	session, ok := server.ClientSessionFromContext(ctx).(server.SessionWithClientInfo)
+	if !ok || session.GetClientCapabilities().Sampling == nil {
+		return "", ErrSamplingUnsupported
+	}
+
+	timeout := h.config.SamplingTimeout
+	if timeout <= 0 {
+		timeout = defaultSamplingTimeout
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if maxTokens <= 0 {
+		maxTokens = 1024
+	}
+	request := mcp.CreateMessageRequest{CreateMessageParams: mcp.CreateMessageParams{
+		SystemPrompt: systemPrompt,
+		MaxTokens:    maxTokens,
+		Messages: []mcp.SamplingMessage{{
+			Role:    mcp.RoleUser,
+			Content: mcp.NewTextContent(prompt),
+		}},
+	}}
+
+	result, err := h.MCPServer().RequestSampling(ctx, request)
+	if err != nil {
+		return "", fmt.Errorf("sampling: %w", err)
+	}
+	switch content := result.Content.(type) {
+	case mcp.TextContent:
+		return content.Text, nil
+	case *mcp.TextContent:
+		return content.Text, nil
+	case map[string]any: // Decoded from JSON by some transports
+		if text, ok := content["text"].(string); ok {
+			return text, nil
+		}
+	}
+	return "", fmt.Errorf("sampling: unsupported content %T, expected text", result.Content)
+}
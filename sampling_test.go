@@ -0,0 +1,202 @@
+package mcpserve
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/mark3labs/mcp-go/client"
+	"github.com/mark3labs/mcp-go/client/transport"
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// summarizer asks the client LLM to summarize its input, with a local sampler type like real handlers
+type summarizer struct {
+	log    func(message ...any)
+	sample func(systemPrompt, prompt string, maxTokens int) (string, error)
+}
+
+func (s *summarizer) Name() string                  { return "summarizer" }
+func (s *summarizer) SetLog(f func(message ...any)) { s.log = f }
+func (s *summarizer) SetMCPSampler(f func(systemPrompt, prompt string, maxTokens int) (string, error)) {
+	s.sample = f
+}
+func (s *summarizer) GetMCPToolsMetadata() []ToolMetadata {
+	return []ToolMetadata{{
+		Name: "summarize",
+		Execute: func(args map[string]any) {
+			summary, err := s.sample("Summarize in one line.", "build log", 0)
+			if errors.Is(err, ErrSamplingUnsupported) {
+				s.log("no sampling, raw log: build log")
+				return
+			}
+			if err != nil {
+				s.log("sampling failed:", err.Error())
+				return
+			}
+			s.log("summary: " + summary)
+		},
+	}}
+}
+
+// llm answers sampling requests like a client-side model would
+type llm struct {
+	answer   string
+	delay    time.Duration
+	mu       sync.Mutex
+	received []mcp.CreateMessageParams
+}
+
+func (l *llm) CreateMessage(ctx context.Context, req mcp.CreateMessageRequest) (*mcp.CreateMessageResult, error) {
+	l.mu.Lock()
+	l.received = append(l.received, req.CreateMessageParams)
+	l.mu.Unlock()
+	select {
+	case <-time.After(l.delay):
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+	return &mcp.CreateMessageResult{
+		SamplingMessage: mcp.SamplingMessage{Role: mcp.RoleAssistant, Content: mcp.NewTextContent(l.answer)},
+		Model:           "test-model",
+	}, nil
+}
+
+// requests returns the sampling requests the model received
+func (l *llm) requests() []mcp.CreateMessageParams {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.received
+}
+
+// samplingClient connects an initialized in-process client, answering sampling with model when not nil
+func samplingClient(t *testing.T, h *Handler, model *llm) *client.Client {
+	t.Helper()
+	var c *client.Client
+	var err error
+	if model != nil {
+		c, err = client.NewInProcessClientWithSamplingHandler(h.MCPServer(), model)
+	} else {
+		c, err = client.NewInProcessClient(h.MCPServer())
+	}
+	if err != nil {
+		t.Fatalf("client: %v", err)
+	}
+	t.Cleanup(func() { c.Close() })
+
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := c.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	return c
+}
+
+// callSummarize calls the summarize tool and returns its text
+func callSummarize(t *testing.T, c *client.Client) string {
+	req := mcp.CallToolRequest{}
+	req.Params.Name = "summarize"
+	result, err := c.CallTool(context.Background(), req)
+	if err != nil {
+		t.Errorf("CallTool: %v", err)
+		return ""
+	}
+	return result.Content[0].(mcp.TextContent).Text
+}
+
+// TestSampling verifies handlers reach the client LLM through the injected sampler
+func TestSampling(t *testing.T) {
+	model := &llm{answer: "all green"}
+	h := NewHandler(Config{}, []any{&summarizer{}}, &mockTUI{})
+	text := callSummarize(t, samplingClient(t, h, model))
+
+	if text != "summary: all green" {
+		t.Errorf("Unexpected result %q", text)
+	}
+	received := model.requests()
+	if len(received) != 1 || received[0].SystemPrompt != "Summarize in one line." || received[0].MaxTokens != 1024 {
+		t.Fatalf("Unexpected sampling requests %+v", received)
+	}
+	if content, ok := received[0].Messages[0].Content.(mcp.TextContent); !ok || content.Text != "build log" {
+		t.Errorf("Unexpected prompt %+v", received[0].Messages)
+	}
+}
+
+// TestSamplingSessions verifies concurrent calls from two clients each sample their own client
+func TestSamplingSessions(t *testing.T) {
+	h := NewHandler(Config{}, []any{&summarizer{}}, &mockTUI{})
+	modelA := &llm{answer: "from A", delay: 30 * time.Millisecond}
+	modelB := &llm{answer: "from B", delay: 30 * time.Millisecond}
+	clientA, clientB := samplingClient(t, h, modelA), samplingClient(t, h, modelB)
+
+	var wg sync.WaitGroup
+	var textA, textB string
+	wg.Add(2)
+	go func() { defer wg.Done(); textA = callSummarize(t, clientA) }()
+	go func() { defer wg.Done(); textB = callSummarize(t, clientB) }()
+	wg.Wait()
+
+	if !strings.HasPrefix(textA, "summary: from A") || !strings.HasPrefix(textB, "summary: from B") {
+		t.Errorf("Sampling crossed sessions: A got %q, B got %q", textA, textB)
+	}
+	if len(modelA.requests()) != 1 || len(modelB.requests()) != 1 {
+		t.Errorf("Each model should get one request, got A=%d B=%d", len(modelA.requests()), len(modelB.requests()))
+	}
+}
+
+// TestSamplingUnsupported verifies the fallback when the client has no sampling capability
+func TestSamplingUnsupported(t *testing.T) {
+	h := NewHandler(Config{}, []any{&summarizer{}}, &mockTUI{})
+	if text := callSummarize(t, samplingClient(t, h, nil)); text != "no sampling, raw log: build log" {
+		t.Errorf("Unexpected result %q", text)
+	}
+}
+
+// TestSamplingTimeout verifies slow clients don't block the tool past Config.SamplingTimeout
+func TestSamplingTimeout(t *testing.T) {
+	h := NewHandler(Config{SamplingTimeout: 20 * time.Millisecond}, []any{&summarizer{}}, &mockTUI{})
+	text := callSummarize(t, samplingClient(t, h, &llm{delay: time.Second}))
+
+	if !strings.HasPrefix(text, "sampling failed:") || !strings.Contains(text, "deadline exceeded") {
+		t.Errorf("Unexpected result %q", text)
+	}
+}
+
+// TestSamplingShutdown verifies a client listening on the stateful session's GET stream doesn't hold up Shutdown
+func TestSamplingShutdown(t *testing.T) {
+	h := NewHandler(Config{Port: "0"}, []any{&summarizer{}}, &mockTUI{})
+	h.SetLog(func(messages ...any) { t.Log(messages...) })
+	done := startTestHandler(t, h)
+
+	c, err := client.NewStreamableHttpClient(mcpServerURL(h.Port()), transport.WithContinuousListening())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	ctx := context.Background()
+	if err := c.Start(ctx); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	if _, err := c.Initialize(ctx, mcp.InitializeRequest{}); err != nil {
+		t.Fatalf("Initialize: %v", err)
+	}
+	time.Sleep(50 * time.Millisecond) // Let the client open its listening stream
+
+	began := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+	if err := h.Shutdown(ctx); err != nil {
+		t.Errorf("Shutdown: %v", err)
+	}
+	if elapsed := time.Since(began); elapsed > time.Second {
+		t.Errorf("Shutdown took %v with no tool call running", elapsed)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Start returned %v", err)
+	}
+}
@@ -25,6 +25,8 @@ type ToolMetadata struct {
 	Description string
 	Parameters  []ParameterMetadata
 	Execute     ToolExecutor // Handler provides execution function
+	Serialized  bool         // Queue calls to all tools of the handler so only one execution runs at a time
+	ReadOnly    bool         // Tool changes nothing the TUI shows: no refresh after calls
 }
 
 // ParameterMetadata describes a tool parameter
@@ -35,6 +37,7 @@ type ParameterMetadata struct {
 	Type        string // "string", "number", "boolean"
 	EnumValues  []string
 	Default     any
+	Sensitive   bool // Secret value: masked in logs, audit entries and tool output
 }
 
 // mcpToolsFromHandler loads all MCP tools from a handler using reflection
@@ -119,6 +122,16 @@ func (h *Handler) convertToToolMetadata(source any) (ToolMetadata, error) {
 		}
 	}
 
+	// Extract Serialized flag
+	if serialField := sourceValue.FieldByName("Serialized"); serialField.IsValid() && serialField.Kind() == reflect.Bool {
+		meta.Serialized = serialField.Bool()
+	}
+
+	// Extract ReadOnly flag
+	if readOnlyField := sourceValue.FieldByName("ReadOnly"); readOnlyField.IsValid() && readOnlyField.Kind() == reflect.Bool {
+		meta.ReadOnly = readOnlyField.Bool()
+	}
+
 	// Extract Execute field (function)
 	if execField := sourceValue.FieldByName("Execute"); execField.IsValid() && execField.Kind() == reflect.Func {
 		// Simply assign the function directly without wrapping
@@ -186,6 +199,11 @@ func convertToParameterMetadata(source any) (ParameterMetadata, error) {
 		param.Default = field.Interface()
 	}
 
+	// Extract Sensitive
+	if field := sourceValue.FieldByName("Sensitive"); field.IsValid() && field.Kind() == reflect.Bool {
+		param.Sensitive = field.Bool()
+	}
+
 	return param, nil
 }
 
@@ -228,10 +246,8 @@ func buildMCPTool(meta ToolMetadata) *mcp.Tool {
 			if param.Description != "" {
 				numOpts = append(numOpts, mcp.Description(param.Description))
 			}
-			if param.Default != nil {
-				if defaultNum, ok := param.Default.(float64); ok {
-					numOpts = append(numOpts, mcp.DefaultNumber(defaultNum))
-				}
+			if defaultNum, ok := numberValue(param.Default); ok {
+				numOpts = append(numOpts, mcp.DefaultNumber(defaultNum))
 			}
 
 			options = append(options, mcp.WithNumber(param.Name, numOpts...))
@@ -246,7 +262,9 @@ func buildMCPTool(meta ToolMetadata) *mcp.Tool {
 			if param.Description != "" {
 				boolOpts = append(boolOpts, mcp.Description(param.Description))
 			}
-			// Note: DefaultBoolean might not exist in mcp-go, skip for now
+			if defaultBool, ok := param.Default.(bool); ok {
+				boolOpts = append(boolOpts, mcp.DefaultBool(defaultBool))
+			}
 
 			options = append(options, mcp.WithBoolean(param.Name, boolOpts...))
 		}
@@ -255,3 +273,31 @@ func buildMCPTool(meta ToolMetadata) *mcp.Tool {
 	tool := mcp.NewTool(meta.Name, options...)
 	return &tool
 }
+
+// numberValue converts any integer or float value (e.g. a Default of 3) to float64
+func numberValue(value any) (float64, bool) {
+	v := reflect.ValueOf(value)
+	if !v.IsValid() || !v.CanConvert(reflect.TypeOf(float64(0))) || v.Kind() == reflect.String {
+		return 0, false
+	}
+	return v.Convert(reflect.TypeOf(float64(0))).Float(), true
+}
+
+// addTool records a registered tool; reports false when the name is already taken
+func (h *Handler) addTool(meta ToolMetadata) bool {
+	h.toolsMu.Lock()
+	defer h.toolsMu.Unlock()
+	if _, exists := h.tools[meta.Name]; exists {
+		return false
+	}
+	h.tools[meta.Name] = meta
+	return true
+}
+
+// tool returns the metadata of a registered tool
+func (h *Handler) tool(name string) (ToolMetadata, bool) {
+	h.toolsMu.RLock()
+	defer h.toolsMu.RUnlock()
+	meta, ok := h.tools[name]
+	return meta, ok
+}
@@ -0,0 +1,204 @@
+package mcpserve
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/mark3labs/mcp-go/mcp"
+)
+
+// Tracer creates spans around tool calls. Set Config.Tracer to enable tracing; adapt it to
+// OpenTelemetry or any other backend, or use MemoryTracer to inspect spans in tests.
+type Tracer interface {
+	// Start begins a span as a child of the span or TraceContext found in ctx
+	Start(ctx context.Context, name string) (context.Context, Span)
+}
+
+// Span is one traced operation
+type Span interface {
+	SetAttribute(key string, value any)
+	End()
+}
+
+// TraceContext is the remote parent of a request, parsed from a W3C traceparent header
+type TraceContext struct {
+	TraceID  string // 32 lowercase hex digits
+	ParentID string // 16 lowercase hex digits, the caller's span
+	Sampled  bool
+}
+
+type traceContextKey struct{}
+
+// TraceContextFromContext returns the incoming W3C trace context of the request, if any
+func TraceContextFromContext(ctx context.Context) (TraceContext, bool) {
+	tc, ok := ctx.Value(traceContextKey{}).(TraceContext)
+	return tc, ok
+}
+
+// withTraceparent is an HTTP context func storing the traceparent header of the request in ctx
+func withTraceparent(ctx context.Context, r *http.Request) context.Context {
+	if tc, ok := parseTraceparent(r.Header.Get("traceparent")); ok {
+		return context.WithValue(ctx, traceContextKey{}, tc)
+	}
+	return ctx
+}
+
+// parseTraceparent parses "00-<trace id>-<parent id>-<flags>"
+func parseTraceparent(header string) (TraceContext, bool) {
+	parts := strings.Split(strings.TrimSpace(header), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" || len(parts[1]) != 32 || len(parts[2]) != 16 || len(parts[3]) != 2 {
+		return TraceContext{}, false
+	}
+	if parts[0] == "00" && len(parts) != 4 {
+		return TraceContext{}, false
+	}
+	for _, part := range parts[:4] {
+		if _, err := hex.DecodeString(part); err != nil || strings.ToLower(part) != part {
+			return TraceContext{}, false
+		}
+	}
+	if strings.Trim(parts[1], "0") == "" || strings.Trim(parts[2], "0") == "" {
+		return TraceContext{}, false // All-zero IDs are invalid
+	}
+	flags, _ := hex.DecodeString(parts[3])
+	return TraceContext{TraceID: parts[1], ParentID: parts[2], Sampled: flags[0]&1 == 1}, true
+}
+
+// startToolSpan starts the span of a tools/call, or returns a nil span when tracing is off
+func (h *Handler) startToolSpan(ctx context.Context, req mcp.CallToolRequest, args map[string]any) (context.Context, Span) {
+	if h.config.Tracer == nil {
+		return ctx, nil
+	}
+	ctx, span := h.config.Tracer.Start(ctx, "tools/call "+req.Params.Name)
+
+	keys := make([]string, 0, len(args))
+	for key := range args {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	span.SetAttribute("mcp.tool.name", req.Params.Name)
+	span.SetAttribute("mcp.tool.arguments", keys) // Keys only: values may be large or sensitive
+	if id := sessionID(ctx); id != "" {
+		span.SetAttribute("mcp.session.id", id)
+	}
+	return ctx, span
+}
+
+// endToolSpan records the outcome of a tools/call and ends its span
+func endToolSpan(span Span, result *mcp.CallToolResult) {
+	if span == nil {
+		return
+	}
+	span.SetAttribute("mcp.tool.result_size", resultSize(result))
+	span.SetAttribute("mcp.tool.is_error", result == nil || result.IsError)
+	span.End()
+}
+
+// resultSize returns the size in bytes of the content of a tool result
+func resultSize(result *mcp.CallToolResult) int {
+	if result == nil {
+		return 0
+	}
+	size := 0
+	for _, content := range result.Content {
+		switch c := content.(type) {
+		case mcp.TextContent:
+			size += len(c.Text)
+		case mcp.ImageContent:
+			size += len(c.Data)
+		}
+	}
+	return size
+}
+
+// MemoryTracer is a Tracer that keeps finished spans in memory, for tests and debugging
+type MemoryTracer struct {
+	mu    sync.Mutex
+	spans []RecordedSpan
+}
+
+// RecordedSpan is a finished span kept by MemoryTracer
+type RecordedSpan struct {
+	Name       string
+	TraceID    string
+	SpanID     string
+	ParentID   string // Empty for root spans
+	Start, End time.Time
+	Attributes map[string]any
+}
+
+type memorySpanKey struct{}
+
+// memorySpan is an in-flight MemoryTracer span
+type memorySpan struct {
+	tracer *MemoryTracer
+	mu     sync.Mutex
+	span   RecordedSpan
+	once   sync.Once
+}
+
+// Start begins a span, continuing the trace of the parent span or incoming traceparent in ctx
+func (t *MemoryTracer) Start(ctx context.Context, name string) (context.Context, Span) {
+	span := &memorySpan{tracer: t, span: RecordedSpan{
+		Name:       name,
+		SpanID:     randomHex(8),
+		Start:      time.Now(),
+		Attributes: map[string]any{},
+	}}
+
+	if parent, ok := ctx.Value(memorySpanKey{}).(*memorySpan); ok {
+		span.span.TraceID, span.span.ParentID = parent.span.TraceID, parent.span.SpanID
+	} else if tc, ok := TraceContextFromContext(ctx); ok {
+		span.span.TraceID, span.span.ParentID = tc.TraceID, tc.ParentID
+	} else {
+		span.span.TraceID = randomHex(16)
+	}
+	return context.WithValue(ctx, memorySpanKey{}, span), span
+}
+
+// Spans returns the finished spans in the order they ended
+func (t *MemoryTracer) Spans() []RecordedSpan {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]RecordedSpan(nil), t.spans...)
+}
+
+// Reset discards the finished spans
+func (t *MemoryTracer) Reset() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.spans = nil
+}
+
+func (s *memorySpan) SetAttribute(key string, value any) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.span.Attributes[key] = value
+}
+
+func (s *memorySpan) End() {
+	s.once.Do(func() {
+		s.mu.Lock()
+		s.span.End = time.Now()
+		recorded := s.span
+		s.mu.Unlock()
+
+		s.tracer.mu.Lock()
+		s.tracer.spans = append(s.tracer.spans, recorded)
+		s.tracer.mu.Unlock()
+	})
+}
+
+// randomHex returns n random bytes as lowercase hex
+func randomHex(n int) string {
+	b := make([]byte, n)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,73 @@
+package mcpserve
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// TestParseTraceparent verifies W3C traceparent parsing
+func TestParseTraceparent(t *testing.T) {
+	const traceID, parentID = "4bf92f3577b34da6a3ce929d0e0e4736", "00f067aa0ba902b7"
+
+	tc, ok := parseTraceparent("00-" + traceID + "-" + parentID + "-01")
+	if !ok || tc.TraceID != traceID || tc.ParentID != parentID || !tc.Sampled {
+		t.Errorf("Unexpected trace context %+v, %v", tc, ok)
+	}
+
+	for _, header := range []string{
+		"",
+		"00-" + traceID + "-" + parentID,
+		"00-" + traceID + "-" + parentID + "-01-extra",
+		"ff-" + traceID + "-" + parentID + "-01",
+		"00-" + strings.ToUpper(traceID) + "-" + parentID + "-01",
+		"00-00000000000000000000000000000000-" + parentID + "-01",
+		"00-" + traceID + "-0000000000000000-01",
+	} {
+		if _, ok := parseTraceparent(header); ok {
+			t.Errorf("Expected %q to be rejected", header)
+		}
+	}
+}
+
+// TestToolCallSpan verifies the span of a tools/call continues the incoming traceparent
+func TestToolCallSpan(t *testing.T) {
+	tracer := &MemoryTracer{}
+	handler := NewHandler(Config{Port: "0", Tracer: tracer}, []any{&mockHandler{}}, &mockTUI{})
+	startTestHandler(t, handler)
+	defer handler.Shutdown(context.Background())
+
+	body := `{"jsonrpc":"2.0","id":1,"method":"tools/call","params":{"name":"test_tool","arguments":{"b":1,"a":"x"}}}`
+	req, _ := http.NewRequest("POST", mcpServerURL(handler.Port()), strings.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Accept", "application/json, text/event-stream")
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+
+	spans := tracer.Spans()
+	if len(spans) != 1 {
+		t.Fatalf("Expected 1 span, got %d", len(spans))
+	}
+	span := spans[0]
+	if span.Name != "tools/call test_tool" {
+		t.Errorf("Unexpected span name %q", span.Name)
+	}
+	if span.TraceID != "4bf92f3577b34da6a3ce929d0e0e4736" || span.ParentID != "00f067aa0ba902b7" {
+		t.Errorf("Span did not continue the incoming trace: %+v", span)
+	}
+	want := map[string]any{
+		"mcp.tool.name":        "test_tool",
+		"mcp.tool.arguments":   []string{"a", "b"},
+		"mcp.tool.result_size": len("Operation completed successfully"),
+		"mcp.tool.is_error":    false,
+	}
+	if !reflect.DeepEqual(span.Attributes, want) {
+		t.Errorf("Unexpected attributes %v", span.Attributes)
+	}
+}
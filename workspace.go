@@ -0,0 +1,101 @@
+package mcpserve
+
+import (
+	"bufio"
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// configureWorkspaceIDEs writes project-level config files into Config.IDEWorkspace.
+// A file is written when its folder already exists in the project (.vscode, .cursor, ...)
+// or when the IDE is installed for the current user.
+func (h *Handler) configureWorkspaceIDEs(ides []IDEInfo) IDEReport {
+	var report IDEReport
+	root := h.config.IDEWorkspace
+	handled := map[string]bool{} // VS Code variants share .vscode/mcp.json
+
+	for _, ide := range ides {
+		if ide.WorkspaceFile == "" || handled[ide.WorkspaceFile] {
+			continue
+		}
+		configPath := filepath.Join(root, ide.WorkspaceFile)
+
+		if !dirExists(filepath.Dir(configPath)) {
+			basePath, _ := ide.GetConfigDir()
+			if !h.ideInstalled(ide, basePath) {
+				// Neither used in this project nor installed
+				report.Results = append(report.Results, IDEResult{
+					IDE: ide.ID, Name: ide.Name, Profile: "workspace", Path: configPath,
+					Status: IDESkipped, Reason: "not installed",
+				})
+				continue
+			}
+			if !h.config.IDEDryRun {
+				if err := os.MkdirAll(filepath.Dir(configPath), 0755); err != nil {
+					report.Results = append(report.Results, IDEResult{
+						IDE: ide.ID, Name: ide.Name, Profile: "workspace", Path: configPath,
+						Found: true, Status: IDEFailed, Err: err,
+					})
+					continue
+				}
+			}
+		}
+
+		handled[ide.WorkspaceFile] = true
+		result := h.configureIDEFile(configPath, ide, "workspace")
+		report.Results = append(report.Results, result)
+
+		if h.config.IDEWorkspaceGitignore && !h.config.IDEDryRun && result.Status != IDEFailed && result.Status != IDESkipped {
+			if err := addToGitignore(root, ide.WorkspaceFile); err != nil {
+				h.log("Warning: could not update .gitignore:", err)
+			}
+		}
+	}
+
+	return report
+}
+
+// addToGitignore lists relPath and its backups (see backupFile) in root/.gitignore
+// Entries that are already present are not repeated.
+func addToGitignore(root string, relPath string) error {
+	file := "/" + filepath.ToSlash(relPath)
+	entries := []string{file, file + ".bak-*"}
+	gitignorePath := filepath.Join(root, ".gitignore")
+
+	data, err := os.ReadFile(gitignorePath)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+
+	present := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		present["/"+strings.TrimPrefix(line, "/")] = true
+	}
+
+	missing := []string{}
+	for _, entry := range entries {
+		if !present[entry] {
+			missing = append(missing, entry)
+		}
+	}
+	if len(missing) == 0 {
+		return nil
+	}
+
+	if len(data) > 0 && !bytes.HasSuffix(data, []byte("\n")) {
+		data = append(data, '\n')
+	}
+	data = append(data, strings.Join(missing, "\n")+"\n"...)
+
+	return os.WriteFile(gitignorePath, data, 0644)
+}
+
+// dirExists reports whether path exists and is a directory
+func dirExists(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.IsDir()
+}